@@ -6,10 +6,19 @@ package qs
 import (
 	"encoding"
 	"errors"
+	"fmt"
 	"net/url"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
+)
+
+var (
+	bindUnmarshalerType         = reflect.TypeOf(new(BindUnmarshaler)).Elem()
+	bindMultipleUnmarshalerType = reflect.TypeOf(new(bindMultipleUnmarshaler)).Elem()
+	textUnmarshalerType         = reflect.TypeOf(new(encoding.TextUnmarshaler)).Elem()
 )
 
 // Binder is the interface that wraps the Bind method.
@@ -18,7 +27,24 @@ type Binder interface {
 }
 
 // DefaultBinder is the default implementation of the Binder interface.
-type DefaultBinder struct{}
+// The zero value binds `query` and `path` tags, it is configured through the
+// Decoder's options.
+type DefaultBinder struct {
+	queryTag        string
+	pathTag         string
+	caseSensitive   bool
+	listFormat      listFormat
+	timeOptions     timeOptions
+	collectErrors   bool
+	duplicatePolicy DuplicatePolicy
+	limits          limits
+	// disallowUnknown reports query parameters that don't bind to any field
+	disallowUnknown bool
+	nullPolicy      NullPolicy
+	nullSentinel    string
+	customTypes     map[reflect.Type]func(param string) (interface{}, error)
+	plans           planStore
+}
 
 // BindUnmarshaler is the interface used to wrap the UnmarshalParam method.
 // Types that don't implement this, but do implement encoding.TextUnmarshaler
@@ -41,12 +67,12 @@ func (b *DefaultBinder) BindPathParams(pathVals map[string]string, i interface{}
 	for name, v := range pathVals {
 		params[name] = []string{v}
 	}
-	return b.bindData(i, params, "path")
+	return b.bindData(i, params, b.pathTagName())
 }
 
 // BindQueryParams binds query params to bindable object
 func (b *DefaultBinder) BindQueryParams(v url.Values, i interface{}) error {
-	return b.bindData(i, v, "query")
+	return b.bindData(i, v, b.queryTagName())
 }
 
 // Bind implements the `Binder#Bind` function.
@@ -56,14 +82,35 @@ func (b *DefaultBinder) Bind(i interface{}, c url.Values) (err error) {
 	return b.BindQueryParams(c, i)
 }
 
+func (b *DefaultBinder) queryTagName() string {
+	if b.queryTag == "" {
+		return "query"
+	}
+	return b.queryTag
+}
+
+func (b *DefaultBinder) pathTagName() string {
+	if b.pathTag == "" {
+		return "path"
+	}
+	return b.pathTag
+}
+
 // bindData will bind data ONLY fields in destination struct that have EXPLICIT tag
 func (b *DefaultBinder) bindData(destination interface{}, data map[string][]string, tag string) error {
-	if destination == nil || len(data) == 0 {
+	if destination == nil {
 		return nil
 	}
 	typ := reflect.TypeOf(destination).Elem()
 	val := reflect.ValueOf(destination).Elem()
 
+	params := newParamSet(data, b.caseSensitive)
+	params.collectErrors = b.collectErrors
+	params.limits = b.limits
+	if err := params.checkLimits(); err != nil {
+		return err
+	}
+
 	// Support binding to limited Map destinations:
 	// - map[string][]string,
 	// - map[string]string <-- (binds first value from data slice)
@@ -78,6 +125,9 @@ func (b *DefaultBinder) bindData(destination interface{}, data map[string][]stri
 		if !(isElemSliceOfStrings || isElemString || isElemInterface) {
 			return nil
 		}
+		if len(params.errs) > 0 {
+			return params.errs
+		}
 		if val.IsNil() {
 			val.Set(reflect.MakeMap(typ))
 		}
@@ -94,112 +144,626 @@ func (b *DefaultBinder) bindData(destination interface{}, data map[string][]stri
 	// !struct
 	if typ.Kind() != reflect.Struct {
 		println("not a struct")
-		if tag == "query" || tag == "path" {
+		if tag == b.queryTagName() || tag == b.pathTagName() {
 			// incompatible type, data is probably to be found in the body
 			return nil
 		}
 		return errors.New("binding element must be a struct")
 	}
 
-	for i := 0; i < typ.NumField(); i++ {
-		typeField := typ.Field(i)
-		structField := val.Field(i)
-		//fmt.Printf("type %#v, struct %#v\n", typeField, structField)
-		if typeField.Anonymous {
-			if structField.Kind() == reflect.Ptr {
-				structField = structField.Elem()
-			}
-		}
-		if !structField.CanSet() {
-			continue
-		}
-		structFieldKind := structField.Kind()
-		inputFieldName := typeField.Tag.Get(tag)
-		if b, _, ok := strings.Cut(inputFieldName, ","); ok {
-			inputFieldName = b
+	plan, err := b.plan(typ, tag)
+	if err != nil {
+		return err
+	}
+	if b.disallowUnknown && tag == b.queryTagName() {
+		if err := b.checkUnknown(plan, params); err != nil {
+			return err
 		}
-		if typeField.Anonymous && structFieldKind == reflect.Struct && inputFieldName != "" {
-			// if anonymous struct with query/param/form tags, report an error
-			return errors.New("query/param/form tags are not allowed with anonymous struct field")
+	}
+	if err := b.bindStruct(val, plan, params, "", ""); err != nil {
+		return err
+	}
+	if len(params.errs) > 0 {
+		return params.errs
+	}
+	return nil
+}
+
+// bindStruct binds params to the fields of the struct value val following
+// plan. Field names are looked up inside scope, so a field tagged `from`
+// within the scope `user` binds the `user[from]` parameter. path is the Go
+// path of val reported by FieldErrors.
+func (b *DefaultBinder) bindStruct(val reflect.Value, plan *structPlan, params *paramSet, scope string, path string) error {
+	for _, fieldPlan := range plan.fields {
+		structField := val.Field(fieldPlan.index)
+		if fieldPlan.anonymousPtr {
+			if structField.IsNil() {
+				continue
+			}
+			structField = structField.Elem()
 		}
 
-		if inputFieldName == "" {
-			// If tag is nil, we inspect if the field is a not BindUnmarshaler struct and try to bind data into it (might contains fields with tags).
-			// structs that implement BindUnmarshaler are bound only when they have explicit tag
-			if _, ok := structField.Addr().Interface().(BindUnmarshaler); !ok && structFieldKind == reflect.Struct {
-				if err := b.bindData(structField.Addr().Interface(), data, tag); err != nil {
+		switch fieldPlan.kind {
+		case planInline:
+			if err := b.bindStruct(structField, fieldPlan.plan, params, scope, path); err != nil {
+				return err
+			}
+		case planNested:
+			key := fieldPlan.key(scope)
+			if fieldPlan.nullable && b.isNullParam(params, key) {
+				structField.Set(reflect.Zero(structField.Type()))
+				continue
+			}
+			if !params.hasScope(key) {
+				if structField.Kind() == reflect.Struct && fieldPlan.plan.defaults {
+					// only the defaults of the nested fields are bound
+					if err := b.bindStruct(structField, fieldPlan.plan, params, key, fieldPlan.path(path)); err != nil {
+						return err
+					}
+				}
+				continue
+			}
+			if err := b.bindStruct(indirect(structField), fieldPlan.plan, params, key, fieldPlan.path(path)); err != nil {
+				return err
+			}
+		case planNestedList:
+			if fieldPlan.nullable && b.isNullParam(params, fieldPlan.key(scope)) {
+				structField.Set(reflect.Zero(structField.Type()))
+				continue
+			}
+			if err := b.bindStructList(structField, fieldPlan, params, fieldPlan.key(scope), fieldPlan.path(path)); err != nil {
+				return err
+			}
+		case planMap:
+			if err := b.bindMap(structField, fieldPlan, params, fieldPlan.key(scope), fieldPlan.path(path)); err != nil {
+				return err
+			}
+		default:
+			var inputValue []string
+			var indexes []int
+			var exists bool
+			key := fieldPlan.key(scope)
+			lowerKey := fieldPlan.lowerName
+			if scope != "" {
+				lowerKey = ""
+			}
+			if fieldPlan.isList {
+				if err := params.checkList(key, fieldPlan.listFormat == arrayFormatIndex, nil); err != nil {
+					if err := params.fail(fieldError(err, key, fieldPlan.path(path), nil)); err != nil {
+						return err
+					}
+					continue
+				}
+				if fieldPlan.setIndexed != nil {
+					inputValue, indexes, exists = params.lookupIndexed(key)
+				} else {
+					inputValue, exists = params.lookupList(key, lowerKey, fieldPlan.listFormat)
+				}
+				if err := params.checkList(key, false, inputValue); err != nil {
+					if err := params.fail(fieldError(err, key, fieldPlan.path(path), nil)); err != nil {
+						return err
+					}
+					continue
+				}
+			} else {
+				inputValue, exists = params.lookup(key, lowerKey)
+			}
+			if !exists {
+				if fieldPlan.defaults == nil {
+					continue
+				}
+				inputValue = fieldPlan.defaults
+			}
+			if fieldPlan.presence {
+				field := presenceOf(indirect(structField))
+				if exists {
+					field.markSet()
+				}
+				structField = field.elem()
+			}
+			if exists && fieldPlan.nullable && b.isNull(inputValue) {
+				structField.Set(reflect.Zero(structField.Type()))
+				continue
+			}
+			var err error
+			if indexes != nil {
+				err = fieldPlan.setIndexed(structField, indexes, inputValue)
+			} else {
+				err = fieldPlan.set(structField, inputValue)
+			}
+			if err != nil {
+				if err := params.fail(fieldError(err, key, fieldPlan.path(path), inputValue)); err != nil {
 					return err
 				}
 			}
-			// does not have explicit tag and is not an ordinary struct - so move to next field
-			continue
 		}
+	}
+	return nil
+}
 
-		inputValue, exists := data[inputFieldName]
-		if !exists {
-			// Go json.Unmarshal supports case insensitive binding.  However the
-			// url params are bound case sensitive which is inconsistent.  To
-			// fix this we must check all of the map values in a
-			// case-insensitive search.
-			for k, v := range data {
-				if strings.EqualFold(k, inputFieldName) {
-					inputValue = v
-					exists = true
-					break
-				}
-			}
+// bindStructList binds `name[i][field]` parameters to a slice or array of
+// structs. A slice holds one element per distinct index, compacted in index
+// order, while the index is the position of an array element and indexes past
+// the end of the array are ignored.
+func (b *DefaultBinder) bindStructList(field reflect.Value, fieldPlan *fieldPlan, params *paramSet, name string, path string) error {
+	if err := params.checkList(name, true, nil); err != nil {
+		return params.fail(fieldError(err, name, path, nil))
+	}
+	indexes := params.scopeIndexes(name)
+	if len(indexes) == 0 {
+		return nil
+	}
+	field = indirect(field)
+	isSlice := field.Kind() == reflect.Slice
+	if isSlice {
+		field.Set(reflect.MakeSlice(field.Type(), len(indexes), len(indexes)))
+	}
+	for j, index := range indexes {
+		position := index
+		if isSlice {
+			position = j
+		}
+		if position >= field.Len() {
+			break
 		}
+		scope := name + "[" + strconv.Itoa(index) + "]"
+		if err := b.bindStruct(indirect(field.Index(position)), fieldPlan.plan, params, scope, path+"["+strconv.Itoa(position)+"]"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-		if !exists {
+// bindMap binds `name[key]=value` parameters to a map field. Keys are
+// converted with the same rules as scalar fields, struct values are bound from
+// `name[key][field]` parameters and slice values from repeated or
+// `name[key][]` parameters.
+func (b *DefaultBinder) bindMap(field reflect.Value, fieldPlan *fieldPlan, params *paramSet, name string, path string) error {
+	prefix := name + "["
+	nested := fieldPlan.plan != nil
+
+	entries := make(map[string][]string)
+	var keys []string
+	for _, k := range params.scan(prefix) {
+		rest := k[len(prefix):]
+		end := strings.IndexByte(rest, ']')
+		if end < 0 {
 			continue
 		}
+		mapKey, suffix := rest[:end], rest[end+1:]
+		var v []string
+		switch {
+		case nested:
+			if !strings.HasPrefix(suffix, "[") {
+				continue
+			}
+		case suffix != "" && suffix != "[]":
+			continue
+		default:
+			v = params.data[k]
+		}
+		if _, ok := entries[mapKey]; !ok {
+			keys = append(keys, mapKey)
+		}
+		entries[mapKey] = append(entries[mapKey], v...)
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	if limit := params.limits.maxMapEntries; limit > 0 && len(keys) > limit {
+		return params.fail(&FieldError{Key: name, Field: path, Err: &LimitError{Limit: LimitMapEntries, Max: limit}})
+	}
 
-		// NOTE: algorithm here is not particularly sophisticated. It probably does not work with absurd types like `**[]*int`
-		// but it is smart enough to handle niche cases like `*int`,`*[]string`,`[]*int` .
-
-		// try unmarshalling first, in case we're dealing with an alias to an array type
-		if ok, err := unmarshalInputsToField(typeField.Type.Kind(), inputValue, structField); ok {
-			if err != nil {
+	field = indirect(field)
+	if field.IsNil() {
+		field.Set(reflect.MakeMap(fieldPlan.mapType))
+	}
+	for _, mapKey := range keys {
+		entryKey := prefix + mapKey + "]"
+		entryPath := path + "[" + mapKey + "]"
+		key := reflect.New(fieldPlan.mapType.Key()).Elem()
+		if err := fieldPlan.setKey(mapKey, key); err != nil {
+			if err := params.fail(fieldError(err, entryKey, entryPath, []string{mapKey})); err != nil {
 				return err
 			}
 			continue
 		}
-
-		if ok, err := unmarshalInputToField(typeField.Type.Kind(), inputValue[0], structField); ok {
-			if err != nil {
+		value := reflect.New(fieldPlan.valueType).Elem()
+		if nested {
+			if err := b.bindStruct(indirect(value), fieldPlan.plan, params, entryKey, entryPath); err != nil {
+				return err
+			}
+		} else if err := fieldPlan.set(value, entries[mapKey]); err != nil {
+			if err := params.fail(fieldError(err, entryKey, entryPath, entries[mapKey])); err != nil {
 				return err
 			}
 			continue
 		}
+		field.SetMapIndex(key, value)
+	}
+	return nil
+}
 
-		// we could be dealing with pointer to slice `*[]string` so dereference it. There are wierd OpenAPI generators
-		// that could create struct fields like that.
-		if structFieldKind == reflect.Pointer {
-			structFieldKind = structField.Elem().Kind()
-			structField = structField.Elem()
+// paramSet indexes the parameters of a single binding and collects its
+// errors. The lower-cased and sorted views used by case-insensitive and
+// scoped lookups are built on first use.
+type paramSet struct {
+	data          map[string][]string
+	caseSensitive bool
+	collectErrors bool
+	errs          FieldErrors
+	limits        limits
+	// lower-cased name to the sorted names of the parameters folding to it
+	folded map[string][]string
+	// sorted parameter names, lower-cased unless case sensitive, and the
+	// parameter names in the same order
+	sorted []string
+	keys   []string
+}
+
+func newParamSet(data map[string][]string, caseSensitive bool) *paramSet {
+	return &paramSet{data: data, caseSensitive: caseSensitive}
+}
+
+// fail returns err, a *FieldError, to stop the binding, or collects it and
+// returns nil so the binding goes on with the next field.
+func (params *paramSet) fail(err error) error {
+	fieldErr, ok := err.(*FieldError)
+	if !ok || !params.collectErrors {
+		return err
+	}
+	params.errs = append(params.errs, fieldErr)
+	return nil
+}
+
+func (params *paramSet) fold(name string) string {
+	if params.caseSensitive {
+		return name
+	}
+	return foldName(name)
+}
+
+// foldName lower-cases the ASCII letters of a parameter name. Unlike
+// strings.ToLower it keeps the byte length, so folded names can be sliced
+// like the original ones.
+func foldName(name string) string {
+	upper := false
+	for i := 0; i < len(name); i++ {
+		if 'A' <= name[i] && name[i] <= 'Z' {
+			upper = true
+			break
 		}
+	}
+	if !upper {
+		return name
+	}
+	folded := []byte(name)
+	for i, c := range folded {
+		if 'A' <= c && c <= 'Z' {
+			folded[i] = c + 'a' - 'A'
+		}
+	}
+	return string(folded)
+}
 
-		if structFieldKind == reflect.Slice {
-			sliceOf := structField.Type().Elem().Kind()
-			numElems := len(inputValue)
-			slice := reflect.MakeSlice(structField.Type(), numElems, numElems)
-			for j := 0; j < numElems; j++ {
-				if err := setWithProperType(sliceOf, inputValue[j], slice.Index(j)); err != nil {
-					return err
-				}
+// lookup returns the values of the named parameter. lowerName is the
+// lower-cased name when the caller already knows it. Unless case sensitive,
+// the values of every parameter whose name folds to the same name are merged,
+// so `limit=1&Limit=2` is a duplicate. The values of the parameter named
+// exactly name come first.
+func (params *paramSet) lookup(name string, lowerName string) ([]string, bool) {
+	if params.caseSensitive {
+		values, exists := params.data[name]
+		return values, exists
+	}
+	// Go json.Unmarshal supports case insensitive binding.  However the
+	// url params are bound case sensitive which is inconsistent.  To
+	// fix this we must check the parameters with a case-insensitive
+	// search.
+	if params.folded == nil {
+		keys := make([]string, 0, len(params.data))
+		for k := range params.data {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		params.folded = make(map[string][]string, len(params.data))
+		for _, k := range keys {
+			lower := foldName(k)
+			params.folded[lower] = append(params.folded[lower], k)
+		}
+	}
+	if lowerName == "" {
+		lowerName = foldName(name)
+	}
+	names, exists := params.folded[lowerName]
+	if !exists {
+		return nil, false
+	}
+	if len(names) == 1 {
+		return params.data[names[0]], true
+	}
+	// copy the values instead of appending to the ones of the data
+	values := params.data[name]
+	values = values[:len(values):len(values)]
+	for _, k := range names {
+		if k != name {
+			values = append(values, params.data[k]...)
+		}
+	}
+	return values, true
+}
+
+// scan returns the sorted names of the parameters beginning with prefix.
+func (params *paramSet) scan(prefix string) []string {
+	if params.sorted == nil {
+		params.keys = make([]string, 0, len(params.data))
+		for k := range params.data {
+			params.keys = append(params.keys, k)
+		}
+		params.sorted = make([]string, len(params.keys))
+		for i, k := range params.keys {
+			params.sorted[i] = params.fold(k)
+		}
+		sort.Sort(foldedKeys{params})
+	}
+	prefix = params.fold(prefix)
+	start := sort.SearchStrings(params.sorted, prefix)
+	end := start
+	for end < len(params.sorted) && strings.HasPrefix(params.sorted[end], prefix) {
+		end++
+	}
+	return params.keys[start:end]
+}
+
+// foldedKeys sorts the parameter names of a paramSet by their folded form.
+type foldedKeys struct {
+	*paramSet
+}
+
+func (f foldedKeys) Len() int { return len(f.sorted) }
+
+func (f foldedKeys) Less(i, j int) bool {
+	if f.sorted[i] == f.sorted[j] {
+		return f.keys[i] < f.keys[j]
+	}
+	return f.sorted[i] < f.sorted[j]
+}
+
+func (f foldedKeys) Swap(i, j int) {
+	f.sorted[i], f.sorted[j] = f.sorted[j], f.sorted[i]
+	f.keys[i], f.keys[j] = f.keys[j], f.keys[i]
+}
+
+// hasScope reports whether there is any parameter nested inside scope.
+func (params *paramSet) hasScope(scope string) bool {
+	return len(params.scan(scope+"[")) > 0
+}
+
+// scopeIndexes returns the sorted distinct indexes i of `name[i][...]`
+// parameters.
+func (params *paramSet) scopeIndexes(name string) []int {
+	prefix := name + "["
+	seen := make(map[int]struct{})
+	var indexes []int
+	for _, k := range params.scan(prefix) {
+		rest := k[len(prefix):]
+		end := strings.IndexByte(rest, ']')
+		if end < 1 || !strings.HasPrefix(rest[end+1:], "[") {
+			continue
+		}
+		index, err := strconv.Atoi(rest[:end])
+		if err != nil || index < 0 {
+			continue
+		}
+		if _, ok := seen[index]; !ok {
+			seen[index] = struct{}{}
+			indexes = append(indexes, index)
+		}
+	}
+	sort.Ints(indexes)
+	return indexes
+}
+
+// lookupList returns the values of a list parameter written in the given
+// list format: `name=a&name=b`, `name[]=a&name[]=b`, `name=a,b` or
+// `name[0]=a&name[1]=b`.
+func (params *paramSet) lookupList(name string, lowerName string, format listFormat) ([]string, bool) {
+	switch format {
+	case arrayFormatBracket:
+		if values, exists := params.lookup(name+"[]", ""); exists {
+			return values, true
+		}
+		return params.lookup(name, lowerName)
+	case arrayFormatComma:
+		values, exists := params.lookup(name, lowerName)
+		if !exists {
+			return nil, false
+		}
+		limit := params.limits.maxListLength
+		split := make([]string, 0, len(values))
+		for _, v := range values {
+			if v == "" {
+				continue
+			}
+			if limit == 0 {
+				split = append(split, strings.Split(v, ",")...)
+				continue
 			}
-			structField.Set(slice)
+			// split no further than one value past the limit, checkList
+			// rejects the list before a long value is expanded
+			split = append(split, strings.SplitN(v, ",", limit+1-len(split))...)
+			if len(split) > limit {
+				break
+			}
+		}
+		return split, true
+	case arrayFormatIndex:
+		values, _, exists := params.lookupIndexed(name)
+		return values, exists
+	default:
+		return params.lookup(name, lowerName)
+	}
+}
+
+// lookupIndexed collects the values of `name[i]` parameters ordered by their
+// index, along with the index of each value. Sparse indexes are compacted, so
+// `name[3]=a&name[1]=b` yields [b a] and the indexes [1 3].
+func (params *paramSet) lookupIndexed(name string) ([]string, []int, bool) {
+	type indexedValue struct {
+		index int
+		value string
+	}
+
+	prefix := name + "["
+	var indexed []indexedValue
+	for _, k := range params.scan(prefix) {
+		if len(k) <= len(prefix)+1 || k[len(k)-1] != ']' {
+			continue
+		}
+		index, err := strconv.Atoi(k[len(prefix) : len(k)-1])
+		if err != nil || index < 0 {
 			continue
 		}
+		for _, value := range params.data[k] {
+			indexed = append(indexed, indexedValue{index: index, value: value})
+		}
+	}
+	if len(indexed) == 0 {
+		return nil, nil, false
+	}
 
-		if err := setWithProperType(structFieldKind, inputValue[0], structField); err != nil {
-			return err
+	// parameter names are sorted, a stable sort keeps equal indexes deterministic
+	sort.SliceStable(indexed, func(i, j int) bool {
+		return indexed[i].index < indexed[j].index
+	})
+	values := make([]string, len(indexed))
+	indexes := make([]int, len(indexed))
+	for i, v := range indexed {
+		values[i] = v.value
+		indexes[i] = v.index
+	}
+	return values, indexes, true
+}
+
+// scopedName returns name scoped with brackets the same way the Encoder
+// writes nested fields, e.g. `user[from]`.
+func scopedName(scope string, name string) string {
+	if scope == "" {
+		return name
+	}
+	return scope + "[" + name + "]"
+}
+
+// isNestedStruct reports whether typ is a struct, or a pointer to one, that
+// is bound field by field rather than through an unmarshaler or a custom type
+// decoder.
+func (b *DefaultBinder) isNestedStruct(typ reflect.Type) bool {
+	if b.isCustomType(typ) {
+		return false
+	}
+	typ = derefType(typ)
+	if typ.Kind() != reflect.Struct {
+		return false
+	}
+	return !isUnmarshaler(typ)
+}
+
+// isMapType reports whether typ is a map, or a pointer to one, that is bound
+// entry by entry rather than through an unmarshaler or a custom type decoder.
+func (b *DefaultBinder) isMapType(typ reflect.Type) bool {
+	if b.isCustomType(typ) {
+		return false
+	}
+	typ = derefType(typ)
+	return typ.Kind() == reflect.Map && !isUnmarshaler(typ)
+}
+
+// isCustomType reports whether typ, or a type it points to, was registered
+// with WithDecoderCustomType.
+func (b *DefaultBinder) isCustomType(typ reflect.Type) bool {
+	for {
+		if _, ok := b.customTypes[typ]; ok {
+			return true
 		}
+		if typ.Kind() != reflect.Ptr {
+			return false
+		}
+		typ = typ.Elem()
 	}
-	return nil
 }
 
+// isUnmarshaler reports whether a pointer to typ unmarshals itself from
+// params.
+func isUnmarshaler(typ reflect.Type) bool {
+	ptr := reflect.PointerTo(typ)
+	return ptr.Implements(bindUnmarshalerType) ||
+		ptr.Implements(bindMultipleUnmarshalerType) ||
+		ptr.Implements(textUnmarshalerType)
+}
+
+// listElemType returns the element type of a slice or array type, following
+// pointers to the list itself.
+func listElemType(typ reflect.Type) reflect.Type {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	return typ.Elem()
+}
+
+// indirect follows pointers, allocating nil ones, and returns the value they
+// point to.
+func indirect(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+// parseTag splits a struct tag into its name and options.
+func parseTag(tag string) (string, []string) {
+	name, opts, ok := strings.Cut(tag, ",")
+	if !ok {
+		return name, nil
+	}
+	return name, strings.Split(opts, ",")
+}
+
+// listFormatOf returns the list format requested by the tag options, or
+// format when there is none. It mirrors the options understood by the
+// Encoder's listField.
+func listFormatOf(tagOptions []string, format listFormat) listFormat {
+	for _, opt := range tagOptions {
+		switch opt {
+		case "comma":
+			format = arrayFormatComma
+		case "bracket":
+			format = arrayFormatBracket
+		case "index":
+			format = arrayFormatIndex
+		}
+	}
+	return format
+}
+
+// isListType reports whether typ, or the type it points to, is a slice or
+// an array.
+func isListType(typ reflect.Type) bool {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	return typ.Kind() == reflect.Slice || typ.Kind() == reflect.Array
+}
+
+// setWithProperType converts val to the kind of structField, a failure is
+// returned as a FieldError carrying val.
 func setWithProperType(valueKind reflect.Kind, val string, structField reflect.Value) error {
+	return valueError(val, setKindField(valueKind, val, structField))
+}
+
+func setKindField(valueKind reflect.Kind, val string, structField reflect.Value) error {
 	// But also call it here, in case we're dealing with an array of BindUnmarshalers
 	if ok, err := unmarshalInputToField(valueKind, val, structField); ok {
 		return err
@@ -207,7 +771,7 @@ func setWithProperType(valueKind reflect.Kind, val string, structField reflect.V
 
 	switch valueKind {
 	case reflect.Ptr:
-		return setWithProperType(structField.Elem().Kind(), val, structField.Elem())
+		return setKindField(structField.Elem().Kind(), val, structField.Elem())
 	case reflect.Int:
 		return setIntField(val, 0, structField)
 	case reflect.Int8:
@@ -237,7 +801,7 @@ func setWithProperType(valueKind reflect.Kind, val string, structField reflect.V
 	case reflect.String:
 		structField.SetString(val)
 	default:
-		return errors.New("unknown type")
+		return ErrUnknownType
 	}
 	return nil
 }
@@ -277,6 +841,51 @@ func unmarshalInputToField(valueKind reflect.Kind, val string, field reflect.Val
 	return false, nil
 }
 
+// timeOptionsOf returns the binder's time options overridden by the tag
+// options, it mirrors the options understood by the Encoder's timeField.
+func (b *DefaultBinder) timeOptionsOf(tagOptions []string) (timeOptions, error) {
+	opts := b.timeOptions
+	for _, opt := range tagOptions {
+		if err := opts.apply(opt); err != nil {
+			return opts, err
+		}
+	}
+	return opts, nil
+}
+
+// setCustomField assigns the value returned by a custom type decoder to field.
+func setCustomField(decode func(param string) (interface{}, error), value string, field reflect.Value) error {
+	v, err := decode(value)
+	if err != nil {
+		return err
+	}
+	if v == nil {
+		field.Set(reflect.Zero(field.Type()))
+		return nil
+	}
+	val := reflect.ValueOf(v)
+	if !val.Type().AssignableTo(field.Type()) {
+		return fmt.Errorf("custom type decoder of %v returned %T", field.Type(), v)
+	}
+	field.Set(val)
+	return nil
+}
+
+// setTimeField parses value as RFC3339, a custom layout or a unix timestamp
+// of the precision given by the time options.
+func setTimeField(value string, opts timeOptions, field reflect.Value) error {
+	if value == "" {
+		field.Set(reflect.ValueOf(time.Time{}))
+		return nil
+	}
+	t, err := opts.parse(value)
+	if err != nil {
+		return err
+	}
+	field.Set(reflect.ValueOf(t))
+	return nil
+}
+
 func setIntField(value string, bitSize int, field reflect.Value) error {
 	if value == "" {
 		value = "0"
@@ -1,9 +1,18 @@
 package qs
 
 import (
+	"errors"
+	"fmt"
+	"net/netip"
 	"net/url"
+	"reflect"
 	"slices"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
 )
 
 const urlq = `/indexes/default?searchableAttributes=title&attributesForFaceting=tags&attributesForFaceting=authors&attributesForFaceting=series&attributesForFaceting=narrators`
@@ -25,7 +34,7 @@ func TestDecoder(t *testing.T) {
 		"index": "default",
 	}
 	dest := params{}
-	dec := NewDecoder(pv)
+	dec := NewDecoder(WithPathValues(pv))
 	err := dec.Decode(urlq, &dest)
 	//q := parsed()
 	//err := Decode(q, &p)
@@ -70,3 +79,495 @@ func parsed() url.Values {
 	v, _ := url.ParseQuery(urlq)
 	return v
 }
+
+func TestDecodeListFormats(t *testing.T) {
+	test := assert.New(t)
+
+	type lists struct {
+		Repeat   []string  `query:"repeat"`
+		Comma    []string  `query:"comma,comma"`
+		Bracket  []string  `query:"bracket,bracket"`
+		Index    []int     `query:"index,index"`
+		Array    [2]string `query:"array,index"`
+		PtrSlice *[]string `query:"ptr_slice,comma"`
+	}
+
+	src := lists{
+		Repeat:   []string{"a", "b"},
+		Comma:    []string{"c", "d"},
+		Bracket:  []string{"e", "f"},
+		Index:    []int{1, 2, 3},
+		Array:    [2]string{"g", "h"},
+		PtrSlice: &[]string{"i", "j"},
+	}
+	values, err := NewEncoder().Values(&src)
+	test.NoError(err)
+
+	var dest lists
+	err = NewDecoder().Decode("/?"+values.Encode(), &dest)
+	test.NoError(err)
+	test.Equal(src, dest)
+}
+
+func TestDecodeSparseIndex(t *testing.T) {
+	test := assert.New(t)
+
+	dest := struct {
+		Tags  []string  `query:"tags,index"`
+		Array [3]string `query:"array,index"`
+	}{}
+	err := NewDecoder().Decode("/?tags[7]=c&tags[2]=b&tags[0]=a&array[5]=y&array[1]=x", &dest)
+	test.NoError(err)
+	test.Equal([]string{"a", "b", "c"}, dest.Tags)
+	test.Equal([3]string{"", "x", ""}, dest.Array)
+
+	type item struct {
+		Name string `query:"name"`
+	}
+	positional := struct {
+		Array [3]string `query:"array,index"`
+		Items [2]item   `query:"items,index"`
+	}{}
+	err = NewDecoder().Decode("/?array[2]=z&items[1][name]=b&items[4][name]=c", &positional)
+	test.NoError(err)
+	test.Equal([3]string{"", "", "z"}, positional.Array)
+	test.Equal([2]item{{}, {Name: "b"}}, positional.Items)
+}
+
+func TestDecodeNestedStruct(t *testing.T) {
+	test := assert.New(t)
+
+	type Address struct {
+		City string `query:"city"`
+		Zip  int    `query:"zip"`
+	}
+
+	type User struct {
+		Verified bool     `query:"verified"`
+		Name     string   `query:"name"`
+		Address  *Address `query:"address"`
+	}
+
+	type Item struct {
+		Name  string `query:"name"`
+		Count int    `query:"count"`
+	}
+
+	type query struct {
+		User   User     `query:"user"`
+		Admin  *User    `query:"admin"`
+		Absent *User    `query:"absent"`
+		Items  []Item   `query:"items,index"`
+		Ptrs   []*Item  `query:"ptrs,index"`
+		Pair   [2]Item  `query:"pair,index"`
+		Tags   []string `query:"tags"`
+	}
+
+	src := query{
+		User: User{
+			Verified: true,
+			Name:     "son",
+			Address:  &Address{City: "Hanoi", Zip: 100000},
+		},
+		Admin: &User{Name: "root"},
+		Items: []Item{{Name: "a", Count: 1}, {Name: "b", Count: 2}},
+		Ptrs:  []*Item{{Name: "c", Count: 3}},
+		Pair:  [2]Item{{Name: "d"}, {Name: "e"}},
+		Tags:  []string{"x"},
+	}
+	values, err := NewEncoder().Values(&src)
+	test.NoError(err)
+
+	var dest query
+	err = NewDecoder().Decode("/?"+values.Encode(), &dest)
+	test.NoError(err)
+	test.Equal(src, dest)
+	test.Nil(dest.Absent)
+}
+
+func TestDecodeMap(t *testing.T) {
+	test := assert.New(t)
+
+	tm := time.Unix(1580601600, 0).UTC()
+
+	src := struct {
+		Counts map[string]int       `query:"counts"`
+		Names  map[int]string       `query:"names"`
+		Times  map[string]time.Time `query:"times"`
+		Ptrs   *map[string]*float64 `query:"ptrs"`
+		Empty  map[string]string    `query:"empty"`
+	}{
+		Counts: map[string]int{"open": 3, "closed": 7},
+		Names:  map[int]string{1: "one", 2: "two"},
+		Times:  map[string]time.Time{"from": tm},
+		Ptrs:   &map[string]*float64{"ratio": withFloat64(0.5)},
+	}
+	values, err := NewEncoder().Values(&src)
+	test.NoError(err)
+
+	dest := src
+	dest.Counts, dest.Names, dest.Times, dest.Ptrs = nil, nil, nil, nil
+	err = NewDecoder().Decode("/?"+values.Encode(), &dest)
+	test.NoError(err)
+	test.Equal(src, dest)
+
+	type Range struct {
+		Min int `query:"min"`
+		Max int `query:"max"`
+	}
+	nested := struct {
+		Lists  map[string][]string `query:"lists"`
+		Ranges map[string]Range    `query:"ranges"`
+	}{}
+	err = NewDecoder().Decode("/?lists[a]=1&lists[a]=2&lists[b][]=3&ranges[price][min]=1&ranges[price][max]=10", &nested)
+	test.NoError(err)
+	test.Equal(map[string][]string{"a": {"1", "2"}, "b": {"3"}}, nested.Lists)
+	test.Equal(map[string]Range{"price": {Min: 1, Max: 10}}, nested.Ranges)
+
+	invalid := struct {
+		Names map[int]string `query:"names"`
+	}{}
+	err = NewDecoder().Decode("/?names[one]=1", &invalid)
+	test.Error(err)
+}
+
+func TestDecodeTime(t *testing.T) {
+	test := assert.New(t)
+
+	tm := time.Unix(1580601600, 0).UTC()
+	ms := time.UnixMilli(1580601600123).UTC()
+
+	type times struct {
+		Rfc3339    time.Time    `query:"default_fmt"`
+		Second     time.Time    `query:"second,second"`
+		Millis     time.Time    `query:"millis,millis"`
+		Rfc3339Ptr *time.Time   `query:"default_fmt_ptr"`
+		SecondPtr  *time.Time   `query:"second_ptr,second"`
+		MillisPtr  *time.Time   `query:"millis_ptr,millis"`
+		SecondList []time.Time  `query:"second_list,second,comma"`
+		MillisList []*time.Time `query:"millis_list,millis,index"`
+	}
+
+	src := times{
+		Rfc3339:    tm,
+		Second:     tm,
+		Millis:     ms,
+		Rfc3339Ptr: &tm,
+		SecondPtr:  &tm,
+		MillisPtr:  &ms,
+		SecondList: []time.Time{tm, tm.Add(time.Hour)},
+		MillisList: []*time.Time{&ms},
+	}
+	values, err := NewEncoder().Values(&src)
+	test.NoError(err)
+
+	var dest times
+	err = NewDecoder().Decode("/?"+values.Encode(), &dest)
+	test.NoError(err)
+	test.Equal(src, dest)
+
+	err = NewDecoder().Decode("/?second=2020-02-02T00:00:00Z", &dest)
+	test.Error(err)
+}
+
+func TestDecodeInvalidTimeZone(t *testing.T) {
+	test := assert.New(t)
+
+	dest := struct {
+		Zone time.Time `query:"zone,tz=Nowhere/Invalid"`
+	}{}
+	err := NewDecoder().Decode("/", &dest)
+	test.Error(err)
+	test.Contains(err.Error(), "Zone")
+}
+
+func TestDecodeTimeLayoutAndZone(t *testing.T) {
+	test := assert.New(t)
+
+	loc, err := time.LoadLocation("Asia/Ho_Chi_Minh")
+	test.NoError(err)
+
+	tm := time.Date(2020, 2, 2, 23, 30, 0, 123456789, time.UTC)
+	day := time.Date(2020, 2, 2, 0, 0, 0, 0, time.UTC)
+
+	type times struct {
+		Date     time.Time            `query:"date,layout=2006-01-02"`
+		Zoned    time.Time            `query:"zoned,tz=Asia/Ho_Chi_Minh"`
+		Micros   time.Time            `query:"micros,micros"`
+		Nanos    *time.Time           `query:"nanos,nanos"`
+		DateList []time.Time          `query:"date_list,comma,layout=2006-01-02"`
+		DateMap  map[string]time.Time `query:"date_map,layout=2006-01-02"`
+		KeyMap   map[time.Time]int    `query:"key_map,second"`
+	}
+
+	src := times{
+		Date:     day,
+		Zoned:    tm.Truncate(time.Second).In(loc),
+		Micros:   tm.Truncate(time.Microsecond),
+		Nanos:    &tm,
+		DateList: []time.Time{day, day.AddDate(0, 0, 1)},
+		DateMap:  map[string]time.Time{"from": day},
+	}
+	values, err := NewEncoder().Values(&src)
+	test.NoError(err)
+
+	var dest times
+	err = NewDecoder().Decode("/?"+values.Encode()+"&key_map[1580601600]=3", &dest)
+	test.NoError(err)
+	src.KeyMap = map[time.Time]int{time.Unix(1580601600, 0).UTC(): 3}
+	test.Equal(src, dest)
+}
+
+func TestDecoderOptions(t *testing.T) {
+	test := assert.New(t)
+
+	type query struct {
+		Index string    `qs:"-" uri:"index"`
+		Tags  []string  `qs:"tags"`
+		Day   time.Time `qs:"day"`
+		Limit int       `qs:"limit"`
+	}
+
+	loc := time.FixedZone("UTC+7", 7*60*60)
+	src := query{
+		Tags:  []string{"a", "b"},
+		Day:   time.Date(2020, 2, 2, 0, 0, 0, 0, loc),
+		Limit: 10,
+	}
+	values, err := NewEncoder(
+		WithTagAlias("qs"),
+		WithTimeLayout("2006-01-02"),
+		WithTimeLocation(loc),
+	).Values(&src)
+	test.NoError(err)
+	test.Equal("a", values.Get("tags"))
+
+	var dest query
+	dec := NewDecoder(
+		WithPathValues(map[string]string{"index": "default"}),
+		WithDecoderTagAlias("qs"),
+		WithPathTagAlias("uri"),
+		WithListFormat("comma"),
+		WithDecoderTimeLayout("2006-01-02"),
+		WithDecoderTimeLocation(loc),
+	)
+	err = dec.Decode("/?tags=a,b&day=2020-02-02&limit=10", &dest)
+	test.NoError(err)
+	src.Index = "default"
+	test.Equal(src, dest)
+
+	dest = query{}
+	err = NewDecoder(WithDecoderTagAlias("qs"), WithCaseSensitive()).Decode("/?Limit=10&limit=5&TAGS=a", &dest)
+	test.NoError(err)
+	test.Equal(5, dest.Limit)
+	test.Nil(dest.Tags)
+
+	dest = query{}
+	err = NewDecoder(WithDecoderTagAlias("qs")).Decode("/?LIMIT=10", &dest)
+	test.NoError(err)
+	test.Equal(10, dest.Limit)
+}
+
+func BenchmarkDecode(b *testing.B) {
+	dec := NewDecoder(WithPathValues(map[string]string{"index": "default"}))
+	values, _ := url.ParseQuery("searchableattributes=title&attributesForFaceting=tags&attributesForFaceting=authors&limit=20")
+	b.ReportAllocs()
+	for n := 0; n < b.N; n++ {
+		var dest params
+		if err := dec.binder.BindQueryParams(values, &dest); err != nil {
+			b.Error(err)
+		}
+	}
+}
+
+func TestDecodeTextUnmarshaler(t *testing.T) {
+	test := assert.New(t)
+
+	type query struct {
+		Addr     netip.Addr            `query:"addr"`
+		AddrPtr  *netip.Addr           `query:"addr_ptr"`
+		Addrs    []netip.Addr          `query:"addrs,comma"`
+		AddrKeys map[netip.Addr]string `query:"addr_keys"`
+	}
+
+	addr := netip.MustParseAddr("10.0.0.1")
+	src := query{
+		Addr:     addr,
+		AddrPtr:  &addr,
+		Addrs:    []netip.Addr{addr, addr.Next()},
+		AddrKeys: map[netip.Addr]string{addr: "gateway"},
+	}
+	values, err := NewEncoder().Values(&src)
+	test.NoError(err)
+
+	var dest query
+	err = NewDecoder().Decode("/?"+values.Encode(), &dest)
+	test.NoError(err)
+	test.Equal(src, dest)
+}
+
+func decodeCoord(param string) (interface{}, error) {
+	var c coord
+	lat, lng, ok := strings.Cut(param, ",")
+	if !ok {
+		return nil, fmt.Errorf("invalid coord %q", param)
+	}
+	var err error
+	if c.Lat, err = strconv.ParseFloat(lat, 64); err != nil {
+		return nil, err
+	}
+	if c.Lng, err = strconv.ParseFloat(lng, 64); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func TestDecodeWithCustomType(t *testing.T) {
+	test := assert.New(t)
+
+	type query struct {
+		Coord    coord            `query:"coord"`
+		CoordPtr *coord           `query:"coord_ptr"`
+		Coords   []coord          `query:"coords,bracket"`
+		Keys     map[coord]string `query:"keys"`
+		Vals     map[string]coord `query:"vals"`
+	}
+
+	home := coord{Lat: 48.85, Lng: 2.35}
+	src := query{
+		Coord:    home,
+		CoordPtr: &home,
+		Coords:   []coord{home, {Lat: 1, Lng: 2}},
+		Keys:     map[coord]string{home: "home"},
+		Vals:     map[string]coord{"home": home},
+	}
+	values, err := NewEncoder(WithCustomType(reflect.TypeOf(coord{}), encodeCoord)).Values(&src)
+	test.NoError(err)
+
+	decoder := NewDecoder(WithDecoderCustomType(reflect.TypeOf(coord{}), decodeCoord))
+	var dest query
+	err = decoder.Decode("/?"+values.Encode(), &dest)
+	test.NoError(err)
+	test.Equal(src, dest)
+
+	err = decoder.Decode("/?coord=north", &dest)
+	test.Error(err)
+
+	// the decoder must return a value of the registered type
+	bad := NewDecoder(WithDecoderCustomType(reflect.TypeOf(coord{}), func(string) (interface{}, error) {
+		return "north", nil
+	}))
+	err = bad.Decode("/?coord=north", &dest)
+	test.Error(err)
+}
+
+// sortOrder implements BindUnmarshaler
+type sortOrder string
+
+func (s *sortOrder) UnmarshalParam(param string) error {
+	if param != "asc" && param != "desc" {
+		return fmt.Errorf("invalid sort order %q", param)
+	}
+	*s = sortOrder(param)
+	return nil
+}
+
+func TestDecodeDuplicatePolicy(t *testing.T) {
+	test := assert.New(t)
+
+	type query struct {
+		Limit   int               `query:"limit"`
+		Page    *int              `query:"page"`
+		Sort    sortOrder         `query:"sort"`
+		Last    string            `query:"last,dup=last"`
+		Strict  int               `query:"strict,dup=error"`
+		First   string            `query:"first,dup=first"`
+		Tags    []string          `query:"tags"`
+		Filter  map[string]string `query:"filter"`
+		Created time.Time         `query:"created,second"`
+	}
+	const uri = "/?limit=10&limit=99999&page=1&page=2&sort=asc&sort=desc&last=a&last=b" +
+		"&first=a&first=b&tags=a&tags=b&filter[k]=a&filter[k]=b&created=1&created=2"
+
+	var first query
+	test.NoError(NewDecoder().Decode(uri, &first))
+	test.Equal(10, first.Limit)
+	test.Equal(1, *first.Page)
+	test.Equal(sortOrder("asc"), first.Sort)
+	test.Equal("b", first.Last)
+	test.Equal("a", first.First)
+	test.Equal([]string{"a", "b"}, first.Tags)
+	test.Equal("a", first.Filter["k"])
+	test.Equal(int64(1), first.Created.Unix())
+
+	var last query
+	test.NoError(NewDecoder(WithDuplicatePolicy(DuplicateLast)).Decode(uri, &last))
+	test.Equal(99999, last.Limit)
+	test.Equal(2, *last.Page)
+	test.Equal(sortOrder("desc"), last.Sort)
+	test.Equal("b", last.Last)
+	test.Equal("a", last.First)
+	test.Equal([]string{"a", "b"}, last.Tags)
+	test.Equal("b", last.Filter["k"])
+	test.Equal(int64(2), last.Created.Unix())
+
+	err := NewDecoder().Decode("/?strict=1&strict=2", &query{})
+	var fieldErr *FieldError
+	if test.True(errors.As(err, &fieldErr)) {
+		test.Equal("strict", fieldErr.Key)
+		test.Equal("1,2", fieldErr.Value)
+	}
+	test.True(errors.Is(err, ErrDuplicateParam))
+
+	reject := NewDecoder(WithDuplicatePolicy(DuplicateError), WithCollectErrors())
+	err = reject.Decode(uri, &query{})
+	var errs FieldErrors
+	if test.True(errors.As(err, &errs)) {
+		keys := make([]string, 0, len(errs))
+		for _, err := range errs {
+			keys = append(keys, err.Key)
+		}
+		test.Equal([]string{"limit", "page", "sort", "filter[k]", "created"}, keys)
+	}
+	test.NoError(reject.Decode("/?limit=1&tags=a&tags=b", &query{}))
+
+	// names differing in case are the same parameter
+	for _, uri := range []string{"/?strict=10&Strict=99999", "/?Strict=10&STRICT=99999"} {
+		err = NewDecoder().Decode(uri, &query{})
+		test.True(errors.Is(err, ErrDuplicateParam), uri)
+	}
+	var merged query
+	test.NoError(NewDecoder().Decode("/?Tags=a&tags=b&TAGS=c", &merged))
+	test.Equal([]string{"b", "c", "a"}, merged.Tags)
+	// the parameter named exactly like the field comes first
+	merged = query{}
+	test.NoError(NewDecoder().Decode("/?limit=1&Limit=2", &merged))
+	test.Equal(1, merged.Limit)
+	merged = query{}
+	test.NoError(NewDecoder().Decode("/?Limit=2&limit=1", &merged))
+	test.Equal(1, merged.Limit)
+	test.NoError(NewDecoder(WithCaseSensitive()).Decode("/?strict=10&Strict=99999", &merged))
+	test.Equal(10, merged.Strict)
+}
+
+func TestDecodeURL(t *testing.T) {
+	test := assert.New(t)
+
+	type query struct {
+		Index string `path:"index"`
+		Q     string `query:"q"`
+	}
+
+	u, err := url.Parse("/indexes/books?q=go")
+	test.NoError(err)
+	decoder := NewDecoder(WithPathValues(map[string]string{"index": "default"}))
+
+	var dest query
+	test.NoError(decoder.DecodeURL(u, map[string]string{"index": "books"}, &dest))
+	test.Equal(query{Index: "books", Q: "go"}, dest)
+
+	// without path values, the ones of WithPathValues are bound
+	dest = query{}
+	test.NoError(decoder.DecodeURL(u, nil, &dest))
+	test.Equal(query{Index: "default", Q: "go"}, dest)
+}
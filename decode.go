@@ -2,18 +2,222 @@ package qs
 
 import (
 	"net/url"
+	"reflect"
+	"time"
+)
+
+// DecoderOption provides option for Decoder
+type DecoderOption func(decoder *Decoder)
+
+// DuplicatePolicy selects the value bound to a single-valued field, a scalar,
+// a pointer to one or a BindUnmarshaler, when its parameter is repeated as in
+// `limit=10&limit=99999`.
+type DuplicatePolicy uint8
+
+const (
+	// DuplicateFirst binds the first value, it is the default
+	DuplicateFirst DuplicatePolicy = iota
+	// DuplicateLast binds the last value
+	DuplicateLast
+	// DuplicateError rejects the parameter with ErrDuplicateParam
+	DuplicateError
 )
 
 // Decoder is the struct for decoding a URL string.
+// Apply options by using WithPathValues, WithRoute, WithDecoderTagAlias,
+// WithPathTagAlias, WithCaseSensitive, WithListFormat, WithDecoderTimeLayout,
+// WithDecoderTimeLocation, WithDecoderCustomType, WithCollectErrors,
+// WithDisallowUnknownParams, WithDuplicatePolicy, WithDecoderNullPolicy,
+// WithDecoderNullSentinel, WithMaxParams, WithMaxValueLength, WithMaxDepth,
+// WithMaxListLength, WithMaxMapEntries
 type Decoder struct {
 	pathVals map[string]string
+	route    *route
+	routeErr error
+	binder   *DefaultBinder
+}
+
+// WithPathValues create a option to bind url path values to fields with a
+// `path` tag.
+func WithPathValues(pathVals map[string]string) DecoderOption {
+	return func(decoder *Decoder) {
+		decoder.pathVals = pathVals
+	}
+}
+
+// WithRoute create a option to match the path of the decoded URLs against
+// the route template and bind its wildcards to the fields with a `path` tag,
+// in place of the values set WithPathValues. Templates are written like the
+// patterns of http.ServeMux without method and host: `{name}` matches a
+// segment, `{name...}` the remaining segments, a trailing slash any remaining
+// path and `{$}` only the trailing slash, e.g. `/indexes/{index}/docs/{id...}`.
+// Decode returns an error wrapping ErrPathMismatch for a path that doesn't
+// match, and reports the `path` tags naming no wildcard of the route like
+// DecodeRequest.
+func WithRoute(template string) DecoderOption {
+	return func(decoder *Decoder) {
+		decoder.route, decoder.routeErr = parseRoute(template)
+	}
+}
+
+// WithDecoderTagAlias create a option to set custom tag alias instead of
+// `query`, use the same alias as the Encoder's WithTagAlias to decode what it
+// encodes.
+func WithDecoderTagAlias(tagAlias string) DecoderOption {
+	return func(decoder *Decoder) {
+		decoder.binder.queryTag = tagAlias
+	}
+}
+
+// WithPathTagAlias create a option to set custom tag alias instead of `path`.
+func WithPathTagAlias(tagAlias string) DecoderOption {
+	return func(decoder *Decoder) {
+		decoder.binder.pathTag = tagAlias
+	}
+}
+
+// WithCaseSensitive create a option to match parameter names case
+// sensitively, by default `Limit=1` binds a field tagged `limit`.
+func WithCaseSensitive() DecoderOption {
+	return func(decoder *Decoder) {
+		decoder.binder.caseSensitive = true
+	}
+}
+
+// WithListFormat create a option to set the format of slices and arrays whose
+// tag has no list option. The format is one of the tag options `comma`,
+// `bracket` or `index`, anything else decodes repeated values.
+func WithListFormat(format string) DecoderOption {
+	return func(decoder *Decoder) {
+		decoder.binder.listFormat = listFormatOf([]string{format}, arrayFormatRepeat)
+	}
+}
+
+// WithDecoderTimeLayout create a option to set the default layout of
+// time.Time values instead of RFC3339. The `second`, `millis`, `micros`,
+// `nanos` and `layout=` tag options take precedence over it.
+func WithDecoderTimeLayout(layout string) DecoderOption {
+	return func(decoder *Decoder) {
+		decoder.binder.timeOptions.layout = layout
+	}
+}
+
+// WithDecoderTimeLocation create a option to set the zone time.Time values
+// are decoded in. The `tz=` tag option takes precedence over it.
+func WithDecoderTimeLocation(loc *time.Location) DecoderOption {
+	return func(decoder *Decoder) {
+		decoder.binder.timeOptions.location = loc
+	}
+}
+
+// WithDecoderCustomType create a option to decode params of typ with decode,
+// for types that can't implement BindUnmarshaler or encoding.TextUnmarshaler.
+// decode must return a value assignable to typ. It applies to fields,
+// pointers, slice and array elements, and map keys and values of typ, and
+// takes precedence over the unmarshalers and the time options.
+func WithDecoderCustomType(typ reflect.Type, decode func(param string) (interface{}, error)) DecoderOption {
+	return func(decoder *Decoder) {
+		if decoder.binder.customTypes == nil {
+			decoder.binder.customTypes = make(map[reflect.Type]func(param string) (interface{}, error))
+		}
+		decoder.binder.customTypes[typ] = decode
+	}
+}
+
+// WithCollectErrors create a option to bind every parameter before reporting
+// failures, instead of stopping at the first one. Decode then returns
+// FieldErrors listing each failing parameter in a deterministic order.
+func WithCollectErrors() DecoderOption {
+	return func(decoder *Decoder) {
+		decoder.binder.collectErrors = true
+	}
+}
+
+// WithDisallowUnknownParams create a option to report query parameters that
+// don't bind to any field, taking the nested scopes and the list formats of
+// the fields into account. Each is reported as a FieldError wrapping an
+// UnknownParamError, which suggests the closest known parameter.
+func WithDisallowUnknownParams() DecoderOption {
+	return func(decoder *Decoder) {
+		decoder.binder.disallowUnknown = true
+	}
+}
+
+// WithDuplicatePolicy create a option to set the policy applied to repeated
+// parameters of single-valued fields. The `dup=first`, `dup=last` and
+// `dup=error` tag options take precedence over it.
+func WithDuplicatePolicy(policy DuplicatePolicy) DecoderOption {
+	return func(decoder *Decoder) {
+		decoder.binder.duplicatePolicy = policy
+	}
+}
+
+// WithDecoderNullPolicy create a option to bind the parameters standing for
+// nil under policy to pointer fields as nil, see NullPolicy. Use the same
+// policy as the Encoder's WithNullPolicy to decode what it encodes.
+func WithDecoderNullPolicy(policy NullPolicy) DecoderOption {
+	return func(decoder *Decoder) {
+		decoder.binder.nullPolicy = policy
+	}
+}
+
+// WithDecoderNullSentinel create a option to bind sentinel instead of `null`
+// to pointer fields as nil, it selects the NullSentinel policy.
+func WithDecoderNullSentinel(sentinel string) DecoderOption {
+	return func(decoder *Decoder) {
+		decoder.binder.nullPolicy = NullSentinel
+		decoder.binder.nullSentinel = sentinel
+	}
 }
 
-// NewDecoder initializes a Decoder with optional url path values.
-func NewDecoder(pathVals ...map[string]string) *Decoder {
-	dec := &Decoder{}
-	if len(pathVals) > 0 {
-		dec.pathVals = pathVals[0]
+// WithMaxParams create a option to reject a query with more than limit
+// parameters, repeated parameters counting once per value. The raw query is
+// counted before it is parsed.
+func WithMaxParams(limit int) DecoderOption {
+	return func(decoder *Decoder) {
+		decoder.binder.limits.maxParams = limit
+	}
+}
+
+// WithMaxValueLength create a option to reject parameter values longer than
+// limit bytes.
+func WithMaxValueLength(limit int) DecoderOption {
+	return func(decoder *Decoder) {
+		decoder.binder.limits.maxValueLength = limit
+	}
+}
+
+// WithMaxDepth create a option to reject parameters nested in more than limit
+// brackets, `user[address][city]` has a depth of 2.
+func WithMaxDepth(limit int) DecoderOption {
+	return func(decoder *Decoder) {
+		decoder.binder.limits.maxDepth = limit
+	}
+}
+
+// WithMaxListLength create a option to reject lists of more than limit values
+// and list indexes from limit on, before the list is allocated.
+func WithMaxListLength(limit int) DecoderOption {
+	return func(decoder *Decoder) {
+		decoder.binder.limits.maxListLength = limit
+	}
+}
+
+// WithMaxMapEntries create a option to reject maps of more than limit entries.
+func WithMaxMapEntries(limit int) DecoderOption {
+	return func(decoder *Decoder) {
+		decoder.binder.limits.maxMapEntries = limit
+	}
+}
+
+// NewDecoder initializes a Decoder.
+// Use DecoderOption to apply options
+func NewDecoder(options ...DecoderOption) *Decoder {
+	dec := &Decoder{
+		binder: &DefaultBinder{},
+	}
+	for _, opt := range options {
+		opt(dec)
 	}
 	return dec
 }
@@ -24,19 +228,106 @@ func (d *Decoder) Decode(uri string, dest any) error {
 	if err != nil {
 		return err
 	}
+	return d.DecodeURL(u, nil, dest)
+}
 
-	bind := &DefaultBinder{}
-	if d.pathVals != nil {
-		err = bind.BindPathParams(d.pathVals, dest)
-		if err != nil {
-			return err
+// DecodeURL decodes a parsed url to a destination struct. pathVals, when not
+// nil, are bound to the fields with a `path` tag in place of the values
+// matched WithRoute or set WithPathValues, so a Decoder can be shared by
+// requests with their own path values.
+func (d *Decoder) DecodeURL(u *url.URL, pathVals map[string]string, dest any) error {
+	if pathVals != nil || d.route == nil && d.routeErr == nil {
+		if pathVals == nil {
+			pathVals = d.pathVals
 		}
+		return d.decode(u, pathVals, nil, dest)
 	}
 
-	err = bind.BindQueryParams(u.Query(), dest)
-	if err != nil {
+	pathVals, err := d.routePathValues(u, dest)
+	if err != nil && !isFieldErrors(err) {
+		return err
+	}
+	return d.decode(u, pathVals, err, dest)
+}
+
+// decode binds pathVals and the query of u to dest and validates it. pathErr
+// reports the path values that couldn't be retrieved, it is returned with the
+// binding errors.
+func (d *Decoder) decode(u *url.URL, pathVals map[string]string, pathErr error, dest any) error {
+	if limit := d.binder.limits.maxParams; limit > 0 && countParams(u.RawQuery) > limit {
+		return &LimitError{Limit: LimitParams, Max: limit}
+	}
+	if pathErr != nil && !d.binder.collectErrors {
+		return pathErr
+	}
+
+	if pathVals != nil {
+		pathErr = joinFieldErrors(pathErr, d.binder.BindPathParams(pathVals, dest))
+		if pathErr != nil && !d.binder.collectErrors {
+			return pathErr
+		}
+	}
+
+	query := u.Query()
+	if d.binder.nullPolicy == NullBareKey {
+		bareParams(u.RawQuery, query)
+	}
+	queryErr := d.binder.BindQueryParams(query, dest)
+	err := joinFieldErrors(pathErr, queryErr)
+	if err != nil && !d.binder.collectErrors {
+		return err
+	}
+	if _, ok := err.(FieldErrors); err != nil && !ok {
+		// not a binding failure of some parameters, nothing is validated
 		return err
 	}
+	pathParams := make(map[string][]string, len(pathVals))
+	for name, v := range pathVals {
+		pathParams[name] = []string{v}
+	}
+	return d.validate(dest, pathParams, query, pathErr, queryErr)
+}
 
-	return nil
+// validate evaluates the `validate` tags of the path and query fields of
+// dest bound from pathParams and query, skipping the parameters that failed
+// to bind. The binding errors of each source are returned along with its
+// validation errors, in the order of the struct fields.
+func (d *Decoder) validate(dest any, pathParams map[string][]string, query url.Values, pathErr error, queryErr error) error {
+	pathErrs, _ := pathErr.(FieldErrors)
+	queryErrs, _ := queryErr.(FieldErrors)
+	failed := make(map[string]bool)
+	for _, err := range append(pathErrs[:len(pathErrs):len(pathErrs)], queryErrs...) {
+		failed[err.Key] = true
+	}
+
+	var errs FieldErrors
+	sources := []struct {
+		tag     string
+		data    map[string][]string
+		bindErr FieldErrors
+	}{
+		{tag: d.binder.pathTagName(), data: pathParams, bindErr: pathErrs},
+		{tag: d.binder.queryTagName(), data: query, bindErr: queryErrs},
+	}
+	for _, source := range sources {
+		tagErrs, err := d.binder.validate(dest, source.tag, source.data, failed)
+		if err != nil {
+			return err
+		}
+		if len(tagErrs) > 0 && !d.binder.collectErrors {
+			return tagErrs[0]
+		}
+		for _, err := range tagErrs {
+			failed[err.Key] = true
+		}
+		sourceErrs := append(source.bindErr[:len(source.bindErr):len(source.bindErr)], tagErrs...)
+		if len(source.bindErr) > 0 && len(tagErrs) > 0 {
+			sortFieldErrors(reflect.TypeOf(dest), sourceErrs)
+		}
+		errs = append(errs, sourceErrs...)
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
 }
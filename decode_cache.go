@@ -0,0 +1,558 @@
+package qs
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+type planKey struct {
+	typ reflect.Type
+	tag string
+}
+
+// planStore caches the decoding plans of a binder, one per struct type and
+// tag name.
+type planStore struct {
+	m     map[planKey]*structPlan
+	mutex sync.RWMutex
+}
+
+// Retrieve structPlan corresponding to reflect.Type and tag
+func (planStore *planStore) Retrieve(typ reflect.Type, tag string) *structPlan {
+	planStore.mutex.RLock()
+	defer planStore.mutex.RUnlock()
+	return planStore.m[planKey{typ: typ, tag: tag}]
+}
+
+// Store func stores structPlan that corresponds to reflect.Type and tag, it
+// returns the plan stored first when two goroutines compiled the same type.
+func (planStore *planStore) Store(typ reflect.Type, tag string, plan *structPlan) *structPlan {
+	planStore.mutex.Lock()
+	defer planStore.mutex.Unlock()
+	if planStore.m == nil {
+		planStore.m = make(map[planKey]*structPlan)
+	}
+	key := planKey{typ: typ, tag: tag}
+	if stored, ok := planStore.m[key]; ok {
+		return stored
+	}
+	planStore.m[key] = plan
+	return plan
+}
+
+type fieldPlanKind uint8
+
+const (
+	// value is bound from the parameter named after the field
+	planValue fieldPlanKind = iota
+	// inline is an untagged struct whose fields are bound in the parent scope
+	planInline
+	// nested is a struct bound from `name[field]` parameters
+	planNested
+	// nestedList is a slice or array of structs bound from `name[i][field]` parameters
+	planNestedList
+	// mapping is a map bound from `name[key]` parameters
+	planMap
+)
+
+type (
+	// setterFunc assigns the parameter values to a field
+	setterFunc func(field reflect.Value, values []string) error
+
+	// elemSetterFunc assigns a single parameter value to a slice, array or map element
+	elemSetterFunc func(val string, elem reflect.Value) error
+
+	// indexedSetterFunc assigns the values of `name[i]` parameters to the
+	// elements i of an array
+	indexedSetterFunc func(field reflect.Value, indexes []int, values []string) error
+
+	// structPlan is the compiled decoding plan of a struct type
+	structPlan struct {
+		fields []*fieldPlan
+		// validates is set when a field or a nested plan has validation rules
+		validates bool
+		// defaults is set when a field or a nested plan has a default value
+		defaults bool
+	}
+
+	// fieldPlan resolves a struct field to its parameter name and setters
+	fieldPlan struct {
+		kind      fieldPlanKind
+		index     int
+		name      string
+		lowerName string
+		// name of the Go field, reported in FieldErrors
+		goName string
+		// anonymous pointer fields are followed only when they are non-nil
+		anonymousPtr bool
+		isList       bool
+		listFormat   listFormat
+		set          setterFunc
+		// setIndexed binds arrays in the index format by position
+		setIndexed indexedSetterFunc
+		// plan of the nested struct, of the list elements or of the map values
+		plan *structPlan
+		// map plans
+		mapType   reflect.Type
+		setKey    elemSetterFunc
+		valueType reflect.Type
+		// rules of the `validate` tag
+		rules []*validationRule
+		// values of the `default` tag, bound when the parameter is absent
+		defaults []string
+		// presence is set for a Field, or a pointer to one, whose value is
+		// bound and which is marked set when the parameter is present
+		presence bool
+		// nullable is set for pointer fields bound as nil when the parameter
+		// is null under the binder's null policy, nested structs and struct
+		// lists included
+		nullable bool
+	}
+)
+
+// key returns the parameter name of the field inside scope.
+func (fieldPlan *fieldPlan) key(scope string) string {
+	if scope == "" {
+		return fieldPlan.name
+	}
+	return scopedName(scope, fieldPlan.name)
+}
+
+// path returns the Go path of the field inside the struct at path.
+func (fieldPlan *fieldPlan) path(path string) string {
+	return joinFieldPath(path, fieldPlan.goName)
+}
+
+// plan returns the cached decoding plan of the struct type typ for tag,
+// compiling it on first use.
+func (b *DefaultBinder) plan(typ reflect.Type, tag string) (*structPlan, error) {
+	if plan := b.plans.Retrieve(typ, tag); plan != nil {
+		return plan, nil
+	}
+	seen := make(map[reflect.Type]*structPlan)
+	plan, err := b.compile(typ, tag, seen)
+	if err != nil {
+		return nil, err
+	}
+	markPlans(seen)
+	return b.plans.Store(typ, tag, plan), nil
+}
+
+// markPlans flags the plans that have validation rules or default values, in
+// their own fields or in nested plans, so validation and defaults skip the
+// others.
+func markPlans(plans map[reflect.Type]*structPlan) {
+	for changed := true; changed; {
+		changed = false
+		for _, plan := range plans {
+			for _, field := range plan.fields {
+				validates := len(field.rules) > 0 || field.plan != nil && field.plan.validates
+				if validates && !plan.validates {
+					plan.validates = true
+					changed = true
+				}
+				defaults := field.defaults != nil || field.kind != planNestedList && field.kind != planMap &&
+					field.plan != nil && field.plan.defaults
+				if defaults && !plan.defaults {
+					plan.defaults = true
+					changed = true
+				}
+			}
+		}
+	}
+}
+
+// compile builds the plan of a struct type. Plans under construction are
+// kept in seen so recursive types reuse them instead of looping.
+func (b *DefaultBinder) compile(typ reflect.Type, tag string, seen map[reflect.Type]*structPlan) (*structPlan, error) {
+	if plan, ok := seen[typ]; ok {
+		return plan, nil
+	}
+	plan := &structPlan{fields: make([]*fieldPlan, 0, typ.NumField())}
+	seen[typ] = plan
+
+	for i := 0; i < typ.NumField(); i++ {
+		typeField := typ.Field(i)
+		if typeField.PkgPath != "" {
+			// unexported field
+			continue
+		}
+		fieldTyp := typeField.Type
+		anonymousPtr := typeField.Anonymous && fieldTyp.Kind() == reflect.Ptr
+		if anonymousPtr {
+			fieldTyp = fieldTyp.Elem()
+		}
+
+		inputFieldName, tagOptions := parseTag(typeField.Tag.Get(tag))
+		if typeField.Anonymous && fieldTyp.Kind() == reflect.Struct && inputFieldName != "" {
+			// if anonymous struct with query/param/form tags, report an error
+			return nil, errors.New("query/param/form tags are not allowed with anonymous struct field")
+		}
+
+		if inputFieldName == "" {
+			// If tag is nil, we inspect if the field is a not BindUnmarshaler struct and try to bind data into it (might contains fields with tags).
+			// structs that implement BindUnmarshaler are bound only when they have explicit tag
+			if fieldTyp.Kind() != reflect.Struct || reflect.PointerTo(fieldTyp).Implements(bindUnmarshalerType) || b.isCustomType(fieldTyp) {
+				// does not have explicit tag and is not an ordinary struct - so move to next field
+				continue
+			}
+			inline, err := b.compile(fieldTyp, tag, seen)
+			if err != nil {
+				return nil, err
+			}
+			plan.fields = append(plan.fields, &fieldPlan{
+				kind:         planInline,
+				index:        i,
+				anonymousPtr: anonymousPtr,
+				plan:         inline,
+			})
+			continue
+		}
+
+		field := &fieldPlan{
+			index:        i,
+			name:         inputFieldName,
+			lowerName:    foldName(inputFieldName),
+			goName:       typeField.Name,
+			anonymousPtr: anonymousPtr,
+		}
+		rules, err := compileRules(typeField, typ, tag)
+		if err != nil {
+			return nil, err
+		}
+		field.rules = rules
+		timeOpts, err := b.timeOptionsOf(tagOptions)
+		if err != nil {
+			return nil, fmt.Errorf("invalid time option for field %s: %w", typeField.Name, err)
+		}
+
+		if isPresenceType(derefType(fieldTyp)) {
+			field.presence = true
+			fieldTyp = presenceElemType(derefType(fieldTyp))
+		}
+
+		switch {
+		case b.isNestedStruct(fieldTyp):
+			nested, err := b.compile(derefType(fieldTyp), tag, seen)
+			if err != nil {
+				return nil, err
+			}
+			field.kind = planNested
+			field.plan = nested
+			field.nullable = fieldTyp.Kind() == reflect.Ptr && !anonymousPtr
+		case b.isMapType(fieldTyp):
+			mapType := derefType(fieldTyp)
+			field.kind = planMap
+			field.mapType = mapType
+			field.setKey = b.newElemSetter(mapType.Key(), timeOpts)
+			field.valueType = mapType.Elem()
+			if b.isNestedStruct(mapType.Elem()) {
+				nested, err := b.compile(derefType(mapType.Elem()), tag, seen)
+				if err != nil {
+					return nil, err
+				}
+				field.plan = nested
+			} else {
+				field.set = b.newSetter(mapType.Elem(), timeOpts)
+				if b.isSingleValue(mapType.Elem()) {
+					field.set = duplicateSetter(b.duplicatePolicyOf(tagOptions), field.set)
+				}
+			}
+		case isListType(fieldTyp) && b.isNestedStruct(listElemType(fieldTyp)):
+			nested, err := b.compile(derefType(listElemType(fieldTyp)), tag, seen)
+			if err != nil {
+				return nil, err
+			}
+			field.kind = planNestedList
+			field.plan = nested
+			field.nullable = fieldTyp.Kind() == reflect.Ptr
+		default:
+			field.kind = planValue
+			field.nullable = fieldTyp.Kind() == reflect.Ptr
+			field.isList = isListType(fieldTyp)
+			field.listFormat = listFormatOf(tagOptions, b.listFormat)
+			field.set = b.newSetter(fieldTyp, timeOpts)
+			if field.isList && field.listFormat == arrayFormatIndex {
+				field.setIndexed = b.newIndexedSetter(fieldTyp, timeOpts)
+			}
+			if b.isSingleValue(fieldTyp) {
+				field.set = duplicateSetter(b.duplicatePolicyOf(tagOptions), field.set)
+			}
+			if isFlag(fieldTyp, tagOptions) {
+				// a bare flag is true, not null
+				field.nullable = false
+				field.set = flagSetter(field.set)
+			}
+		}
+
+		if field.presence && field.kind != planValue {
+			return nil, fmt.Errorf("field %s of type %v is not supported, Field holds values bound from a single parameter", typeField.Name, typeField.Type)
+		}
+		if text, ok := typeField.Tag.Lookup(defaultTag); ok {
+			if field.kind != planValue {
+				return nil, fmt.Errorf("default is not supported for field %s of type %v", typeField.Name, typeField.Type)
+			}
+			field.defaults = defaultValues(text, !b.isSingleValue(fieldTyp))
+			if _, err := b.parseDefault(fieldTyp, field.defaults, timeOpts); err != nil {
+				return nil, fmt.Errorf("invalid default %q for field %s: %w", text, typeField.Name, err)
+			}
+		}
+		plan.fields = append(plan.fields, field)
+	}
+	return plan, nil
+}
+
+// newSetter returns the setter assigning parameter values to a field of type
+// typ, which may be a scalar, a pointer, a slice or an array. Types registered
+// with WithDecoderCustomType take precedence over everything else, the time
+// options select the format of time.Time values.
+func (b *DefaultBinder) newSetter(typ reflect.Type, timeOpts timeOptions) setterFunc {
+	if decode, ok := b.customTypes[typ]; ok {
+		return func(field reflect.Value, values []string) error {
+			if len(values) == 0 {
+				return nil
+			}
+			return setCustomField(decode, values[0], field)
+		}
+	}
+	if typ == timeType {
+		return func(field reflect.Value, values []string) error {
+			if len(values) == 0 {
+				return nil
+			}
+			return setTimeField(values[0], timeOpts, field)
+		}
+	}
+
+	// we could be dealing with pointer to slice `*[]string` so dereference it. There are wierd OpenAPI generators
+	// that could create struct fields like that.
+	if typ.Kind() == reflect.Ptr {
+		set := b.newSetter(typ.Elem(), timeOpts)
+		return func(field reflect.Value, values []string) error {
+			if field.IsNil() {
+				field.Set(reflect.New(field.Type().Elem()))
+			}
+			return set(field.Elem(), values)
+		}
+	}
+
+	// try unmarshalling first, in case we're dealing with an alias to an array type
+	ptr := reflect.PointerTo(typ)
+	if ptr.Implements(bindMultipleUnmarshalerType) {
+		return func(field reflect.Value, values []string) error {
+			_, err := unmarshalInputsToField(field.Kind(), values, field)
+			return err
+		}
+	}
+	if ptr.Implements(bindUnmarshalerType) || ptr.Implements(textUnmarshalerType) {
+		return func(field reflect.Value, values []string) error {
+			if len(values) == 0 {
+				return nil
+			}
+			_, err := unmarshalInputToField(field.Kind(), values[0], field)
+			return err
+		}
+	}
+
+	switch typ.Kind() {
+	case reflect.Slice:
+		setElem := b.newElemSetter(typ.Elem(), timeOpts)
+		return func(field reflect.Value, values []string) error {
+			numElems := len(values)
+			slice := reflect.MakeSlice(field.Type(), numElems, numElems)
+			for j := 0; j < numElems; j++ {
+				if err := setElem(values[j], slice.Index(j)); err != nil {
+					return err
+				}
+			}
+			field.Set(slice)
+			return nil
+		}
+	case reflect.Array:
+		setElem := b.newElemSetter(typ.Elem(), timeOpts)
+		return func(field reflect.Value, values []string) error {
+			array := reflect.New(field.Type()).Elem()
+			for j := 0; j < len(values) && j < array.Len(); j++ {
+				if err := setElem(values[j], array.Index(j)); err != nil {
+					return err
+				}
+			}
+			field.Set(array)
+			return nil
+		}
+	default:
+		setElem := b.newElemSetter(typ, timeOpts)
+		return func(field reflect.Value, values []string) error {
+			if len(values) == 0 {
+				return nil
+			}
+			return setElem(values[0], field)
+		}
+	}
+}
+
+// newIndexedSetter returns the setter assigning the values of `name[i]`
+// parameters to the elements i of an array of type typ, or nil when typ is not
+// an array bound element by element. Indexes past the end of the array are
+// ignored and the first value wins when an index is repeated.
+func (b *DefaultBinder) newIndexedSetter(typ reflect.Type, timeOpts timeOptions) indexedSetterFunc {
+	if _, ok := b.customTypes[typ]; ok {
+		return nil
+	}
+	if typ.Kind() == reflect.Ptr {
+		set := b.newIndexedSetter(typ.Elem(), timeOpts)
+		if set == nil {
+			return nil
+		}
+		return func(field reflect.Value, indexes []int, values []string) error {
+			if field.IsNil() {
+				field.Set(reflect.New(field.Type().Elem()))
+			}
+			return set(field.Elem(), indexes, values)
+		}
+	}
+	ptr := reflect.PointerTo(typ)
+	if typ.Kind() != reflect.Array || ptr.Implements(bindMultipleUnmarshalerType) ||
+		ptr.Implements(bindUnmarshalerType) || ptr.Implements(textUnmarshalerType) {
+		return nil
+	}
+
+	setElem := b.newElemSetter(typ.Elem(), timeOpts)
+	return func(field reflect.Value, indexes []int, values []string) error {
+		array := reflect.New(field.Type()).Elem()
+		for j, index := range indexes {
+			if index >= array.Len() || j > 0 && indexes[j-1] == index {
+				continue
+			}
+			if err := setElem(values[j], array.Index(index)); err != nil {
+				return err
+			}
+		}
+		field.Set(array)
+		return nil
+	}
+}
+
+// newElemSetter returns the setter assigning a single parameter value to a
+// slice, array or map element of type typ.
+func (b *DefaultBinder) newElemSetter(typ reflect.Type, timeOpts timeOptions) elemSetterFunc {
+	if decode, ok := b.customTypes[typ]; ok {
+		return func(val string, elem reflect.Value) error {
+			return valueError(val, setCustomField(decode, val, elem))
+		}
+	}
+	if typ == timeType {
+		return func(val string, elem reflect.Value) error {
+			return valueError(val, setTimeField(val, timeOpts, elem))
+		}
+	}
+	if typ.Kind() == reflect.Ptr {
+		set := b.newElemSetter(typ.Elem(), timeOpts)
+		return func(val string, elem reflect.Value) error {
+			if elem.IsNil() {
+				elem.Set(reflect.New(elem.Type().Elem()))
+			}
+			return set(val, elem.Elem())
+		}
+	}
+	kind := typ.Kind()
+	return func(val string, elem reflect.Value) error {
+		return setWithProperType(kind, val, elem)
+	}
+}
+
+// isSingleValue reports whether the setter of typ assigns a single parameter
+// value, as it does for scalars, times, custom types and BindUnmarshalers,
+// rather than every value of the parameter.
+func (b *DefaultBinder) isSingleValue(typ reflect.Type) bool {
+	if b.isCustomType(typ) {
+		return true
+	}
+	typ = derefType(typ)
+	if typ == timeType {
+		return true
+	}
+	ptr := reflect.PointerTo(typ)
+	if ptr.Implements(bindMultipleUnmarshalerType) {
+		return false
+	}
+	if ptr.Implements(bindUnmarshalerType) || ptr.Implements(textUnmarshalerType) {
+		return true
+	}
+	return typ.Kind() != reflect.Slice && typ.Kind() != reflect.Array
+}
+
+// isFlag reports whether a field of type typ is a bool, or a pointer to one,
+// with the `flag` tag option.
+func isFlag(typ reflect.Type, tagOptions []string) bool {
+	if derefType(typ).Kind() != reflect.Bool {
+		return false
+	}
+	for _, opt := range tagOptions {
+		if opt == tagFlag {
+			return true
+		}
+	}
+	return false
+}
+
+// flagSetter wraps the setter of a flag to bind a bare or empty parameter,
+// `debug` or `debug=`, as true.
+func flagSetter(set setterFunc) setterFunc {
+	return func(field reflect.Value, values []string) error {
+		if len(values) == 0 {
+			values = []string{""}
+		}
+		flags := make([]string, len(values))
+		for i, v := range values {
+			if v == "" {
+				v = "true"
+			}
+			flags[i] = v
+		}
+		return set(field, flags)
+	}
+}
+
+// duplicatePolicyOf returns the binder's duplicate policy overridden by the
+// `dup=` tag option.
+func (b *DefaultBinder) duplicatePolicyOf(tagOptions []string) DuplicatePolicy {
+	policy := b.duplicatePolicy
+	for _, opt := range tagOptions {
+		switch opt {
+		case "dup=first":
+			policy = DuplicateFirst
+		case "dup=last":
+			policy = DuplicateLast
+		case "dup=error":
+			policy = DuplicateError
+		}
+	}
+	return policy
+}
+
+// duplicateSetter wraps the setter of a single-valued field to pick the value
+// of a repeated parameter according to policy.
+func duplicateSetter(policy DuplicatePolicy, set setterFunc) setterFunc {
+	switch policy {
+	case DuplicateLast:
+		return func(field reflect.Value, values []string) error {
+			if len(values) > 1 {
+				values = values[len(values)-1:]
+			}
+			return set(field, values)
+		}
+	case DuplicateError:
+		return func(field reflect.Value, values []string) error {
+			if len(values) > 1 {
+				return &FieldError{Value: strings.Join(values, ","), Err: ErrDuplicateParam}
+			}
+			return set(field, values)
+		}
+	default:
+		return set
+	}
+}
@@ -0,0 +1,60 @@
+package qs
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlanStore(t *testing.T) {
+	test := assert.New(t)
+
+	typ := reflect.TypeOf(params{})
+	store := &planStore{}
+	test.Nil(store.Retrieve(typ, "query"))
+
+	plan := &structPlan{}
+	test.True(plan == store.Store(typ, "query", plan))
+	test.True(plan == store.Retrieve(typ, "query"))
+	test.Nil(store.Retrieve(typ, "path"))
+
+	// the first stored plan wins
+	test.True(plan == store.Store(typ, "query", &structPlan{}))
+}
+
+func TestPlanIsCached(t *testing.T) {
+	test := assert.New(t)
+
+	dec := NewDecoder()
+	var dest params
+	test.NoError(dec.Decode(urlq, &dest))
+
+	plan := dec.binder.plans.Retrieve(reflect.TypeOf(dest), "query")
+	test.NotNil(plan)
+	test.Len(plan.fields, 2)
+	test.Equal("searchableattributes", plan.fields[0].lowerName)
+
+	test.NoError(dec.Decode(urlq, &dest))
+	test.True(plan == dec.binder.plans.Retrieve(reflect.TypeOf(dest), "query"))
+}
+
+type recursive struct {
+	Name     string       `query:"name"`
+	Parent   *recursive   `query:"parent"`
+	Children []*recursive `query:"children,index"`
+}
+
+func TestPlanRecursiveType(t *testing.T) {
+	test := assert.New(t)
+
+	var dest recursive
+	err := NewDecoder().Decode("/?name=a&parent[name]=b&parent[parent][name]=c&children[0][name]=d", &dest)
+	test.NoError(err)
+	test.Equal("a", dest.Name)
+	test.Equal("b", dest.Parent.Name)
+	test.Equal("c", dest.Parent.Parent.Name)
+	test.Nil(dest.Parent.Parent.Parent)
+	test.Len(dest.Children, 1)
+	test.Equal("d", dest.Children[0].Name)
+}
@@ -0,0 +1,86 @@
+package qs
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+// defaultTag is the struct tag holding the value bound to a field whose
+// parameter is absent
+const defaultTag = "default"
+
+// defaultValues splits the text of a `default` tag into parameter values,
+// lists take comma separated values.
+func defaultValues(text string, list bool) []string {
+	if !list {
+		return []string{text}
+	}
+	if text == "" {
+		return []string{}
+	}
+	return strings.Split(text, ",")
+}
+
+// parseDefault decodes the values of a `default` tag into a new value of typ.
+func (b *DefaultBinder) parseDefault(typ reflect.Type, values []string, timeOpts timeOptions) (reflect.Value, error) {
+	value := reflect.New(typ).Elem()
+	if err := b.newSetter(typ, timeOpts)(value, values); err != nil {
+		return reflect.Value{}, err
+	}
+	return value, nil
+}
+
+// equalDefault reports whether v, following pointers, equals the default
+// value def. Times are equal when they are the same instant.
+func equalDefault(v reflect.Value, def reflect.Value) bool {
+	v, def = derefValue(v), derefValue(def)
+	if !v.IsValid() || !def.IsValid() {
+		return v.IsValid() == def.IsValid()
+	}
+	if v.Type() != def.Type() {
+		return false
+	}
+	if v.Type() == timeType {
+		return v.Interface().(time.Time).Equal(def.Interface().(time.Time))
+	}
+	return reflect.DeepEqual(v.Interface(), def.Interface())
+}
+
+// cacheDefaults wraps the cached fields of the struct type typ that are
+// omitted when they equal their `default` tag. A default the Decoder can't
+// parse never matches, so the field is always encoded.
+func (e *Encoder) cacheDefaults(fields cachedFields, typ reflect.Type) {
+	for i, field := range fields {
+		structField := typ.Field(i)
+		text, ok := structField.Tag.Lookup(defaultTag)
+		if _, isPath := field.(*pathField); field == nil || isPath || !ok {
+			continue
+		}
+		_, tagOptions := parseTag(structField.Tag.Get(e.tagAlias))
+		omit := e.omitDefaults
+		for _, opt := range tagOptions {
+			omit = omit || opt == tagOmitDefault
+		}
+		if !omit {
+			continue
+		}
+
+		binder := &DefaultBinder{timeOptions: e.timeOptions}
+		fieldTyp := derefType(structField.Type)
+		if isPresenceType(fieldTyp) {
+			fieldTyp = derefType(presenceElemType(fieldTyp))
+		}
+		values := defaultValues(text, !binder.isSingleValue(fieldTyp))
+		timeOpts, err := binder.timeOptionsOf(tagOptions)
+		if err != nil {
+			// the timeField reports the option when the field is encoded
+			continue
+		}
+		value, err := binder.parseDefault(fieldTyp, values, timeOpts)
+		if err != nil {
+			continue
+		}
+		fields[i] = &defaultField{cachedField: field, value: value}
+	}
+}
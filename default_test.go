@@ -0,0 +1,125 @@
+package qs
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeDefault(t *testing.T) {
+	test := assert.New(t)
+
+	type page struct {
+		Size int `query:"size" default:"10"`
+	}
+	type query struct {
+		Limit   int       `query:"limit" default:"20"`
+		Offset  *int      `query:"offset" default:"5"`
+		Tags    []string  `query:"tags" default:"a,b"`
+		IDs     []int     `query:"ids,comma" default:"1,2"`
+		Since   time.Time `query:"since,second" default:"600"`
+		Sort    sortOrder `query:"sort" default:"desc"`
+		Page    page      `query:"page"`
+		PagePtr *page     `query:"page_ptr"`
+		Q       string    `query:"q"`
+		Index   string    `path:"index" default:"main"`
+	}
+
+	var dest query
+	test.NoError(NewDecoder().Decode("/?q=x", &dest))
+	test.Equal(20, dest.Limit)
+	if test.NotNil(dest.Offset) {
+		test.Equal(5, *dest.Offset)
+	}
+	test.Equal([]string{"a", "b"}, dest.Tags)
+	test.Equal([]int{1, 2}, dest.IDs)
+	test.Equal(int64(600), dest.Since.Unix())
+	test.Equal(sortOrder("desc"), dest.Sort)
+	test.Equal(10, dest.Page.Size)
+	test.Nil(dest.PagePtr)
+	test.Equal("x", dest.Q)
+	test.Equal("", dest.Index)
+
+	// given parameters, even empty ones, take precedence over defaults
+	dest = query{}
+	test.NoError(NewDecoder(WithPathValues(map[string]string{"index": "books"})).
+		Decode("/?limit=&tags=c&page[size]=3&page_ptr[size]=4&sort=asc", &dest))
+	test.Equal(0, dest.Limit)
+	test.Equal([]string{"c"}, dest.Tags)
+	test.Equal(3, dest.Page.Size)
+	if test.NotNil(dest.PagePtr) {
+		test.Equal(4, dest.PagePtr.Size)
+	}
+	test.Equal(sortOrder("asc"), dest.Sort)
+	test.Equal("books", dest.Index)
+
+	// path defaults are bound with the path values
+	dest = query{}
+	test.NoError(NewDecoder(WithPathValues(map[string]string{})).Decode("/", &dest))
+	test.Equal("main", dest.Index)
+
+	test.Error(NewDecoder().Decode("/", &struct {
+		Limit int `query:"limit" default:"twenty"`
+	}{}))
+	test.Error(NewDecoder().Decode("/", &struct {
+		Sort sortOrder `query:"sort" default:"up"`
+	}{}))
+	test.Error(NewDecoder().Decode("/", &struct {
+		Filter map[string]string `query:"filter" default:"a"`
+	}{}))
+}
+
+func TestEncodeOmitDefaults(t *testing.T) {
+	test := assert.New(t)
+
+	type query struct {
+		Limit  int       `query:"limit" default:"20"`
+		Offset *int      `query:"offset" default:"0"`
+		Tags   []string  `query:"tags" default:"a,b"`
+		Since  time.Time `query:"since,second" default:"600"`
+		Sort   sortOrder `query:"sort" default:"desc"`
+		Q      string    `query:"q"`
+	}
+
+	offset := 0
+	src := query{
+		Limit:  20,
+		Offset: &offset,
+		Tags:   []string{"a", "b"},
+		Since:  time.Unix(600, 0),
+		Sort:   "desc",
+		Q:      "x",
+	}
+	values, err := NewEncoder(WithOmitDefaults()).Values(src)
+	test.NoError(err)
+	test.Equal(url.Values{"q": []string{"x"}}, values)
+
+	var dest query
+	test.NoError(NewDecoder().Decode("/?"+values.Encode(), &dest))
+	test.Equal(src.Limit, dest.Limit)
+	test.Equal(src.Tags, dest.Tags)
+	test.True(src.Since.Equal(dest.Since))
+
+	// a nil pointer isn't its default
+	src = query{Limit: 0, Tags: []string{"a"}, Since: time.Unix(601, 0), Sort: "asc"}
+	values, err = NewEncoder(WithOmitDefaults()).Values(src)
+	test.NoError(err)
+	test.Equal(url.Values{
+		"limit":  []string{"0"},
+		"offset": []string{""},
+		"tags":   []string{"a"},
+		"since":  []string{"601"},
+		"sort":   []string{"asc"},
+		"q":      []string{""},
+	}, values)
+
+	// the defaults are encoded unless the field or the encoder omits them
+	values, err = NewEncoder().Values(struct {
+		Limit int `query:"limit" default:"20"`
+		Size  int `query:"size,omitdefault" default:"10"`
+	}{Limit: 20, Size: 10})
+	test.NoError(err)
+	test.Equal(url.Values{"limit": []string{"20"}}, values)
+}
@@ -23,8 +23,11 @@
 package qs
 
 import (
+	"encoding"
+	"fmt"
 	"net/url"
 	"reflect"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -33,31 +36,47 @@ import (
 )
 
 const (
-	tagOmitEmpty = "omitempty"
+	tagOmitEmpty   = "omitempty"
+	tagStringer    = "stringer"
+	tagOmitDefault = "omitdefault"
+	tagFlag        = "flag"
 )
 
 var (
-	timeType    = reflect.TypeOf(time.Time{})
-	encoderType = reflect.TypeOf(new(QueryParamEncoder)).Elem()
-	zeroerType  = reflect.TypeOf(new(Zeroer)).Elem()
+	timeType          = reflect.TypeOf(time.Time{})
+	encoderType       = reflect.TypeOf(new(QueryParamEncoder)).Elem()
+	zeroerType        = reflect.TypeOf(new(Zeroer)).Elem()
+	stringerType      = reflect.TypeOf(new(fmt.Stringer)).Elem()
+	textMarshalerType = reflect.TypeOf(new(encoding.TextMarshaler)).Elem()
 )
 
 // EncoderOption provides option for Encoder
 type EncoderOption func(encoder *Encoder)
 
 // Encoder is the main instance
-// Apply options by using WithTagAlias, WithCustomType
+// Apply options by using WithTagAlias, WithEncoderPathTagAlias, WithTimeLayout,
+// WithTimeLocation, WithCustomType, WithOmitDefaults, WithNullPolicy,
+// WithNullSentinel
 type Encoder struct {
-	tagAlias string
-	cache    *cacheStore
-	dataPool *sync.Pool
+	tagAlias     string
+	pathTag      string
+	timeOptions  timeOptions
+	omitDefaults bool
+	nullPolicy   NullPolicy
+	nullSentinel string
+	customTypes  map[reflect.Type]func(value interface{}) (string, error)
+	cache        *cacheStore
+	dataPool     *sync.Pool
 }
 
 type encoder struct {
-	e      *Encoder
-	values url.Values
-	tags   [][]byte
-	scope  []byte
+	e     *Encoder
+	tags  [][]byte
+	scope []byte
+	// pathValues receives the path parameters, they are dropped when nil
+	pathValues url.Values
+	// names records the names of the parameters in encoding order when not nil
+	names []string
 }
 
 // WithTagAlias create a option to set custom tag alias instead of `query`
@@ -67,11 +86,79 @@ func WithTagAlias(tagAlias string) EncoderOption {
 	}
 }
 
+// WithEncoderPathTagAlias create a option to set custom tag alias instead of
+// `path`, use the same alias as the Decoder's WithPathTagAlias. Fields with
+// this tag and no query tag are path parameters, see Encoder.URL.
+func WithEncoderPathTagAlias(tagAlias string) EncoderOption {
+	return func(encoder *Encoder) {
+		encoder.pathTag = tagAlias
+	}
+}
+
+// WithTimeLayout create a option to set the default layout of time.Time values
+// instead of RFC3339. The `second`, `millis`, `micros`, `nanos` and `layout=`
+// tag options take precedence over it.
+func WithTimeLayout(layout string) EncoderOption {
+	return func(encoder *Encoder) {
+		encoder.timeOptions.layout = layout
+	}
+}
+
+// WithTimeLocation create a option to set the default zone time.Time values are
+// encoded in. The `tz=` tag option takes precedence over it.
+func WithTimeLocation(loc *time.Location) EncoderOption {
+	return func(encoder *Encoder) {
+		encoder.timeOptions.location = loc
+	}
+}
+
+// WithCustomType create a option to encode values of typ with encode, for
+// types that can't implement QueryParamEncoder or encoding.TextMarshaler.
+// encode receives values of typ, it applies to fields, pointers, slice and
+// array elements, and map keys and values of typ, and takes precedence over
+// the interfaces and the time options.
+func WithCustomType(typ reflect.Type, encode func(value interface{}) (string, error)) EncoderOption {
+	return func(encoder *Encoder) {
+		if encoder.customTypes == nil {
+			encoder.customTypes = make(map[reflect.Type]func(value interface{}) (string, error))
+		}
+		encoder.customTypes[typ] = encode
+	}
+}
+
+// WithNullPolicy create a option to set how nil pointers are encoded, see
+// NullPolicy. Fields with the `omitempty` option always omit them. Values
+// keeps bare keys without values, use EncodeValues to write them.
+func WithNullPolicy(policy NullPolicy) EncoderOption {
+	return func(encoder *Encoder) {
+		encoder.nullPolicy = policy
+	}
+}
+
+// WithNullSentinel create a option to encode nil pointers as sentinel instead
+// of `null`, it selects the NullSentinel policy.
+func WithNullSentinel(sentinel string) EncoderOption {
+	return func(encoder *Encoder) {
+		encoder.nullPolicy = NullSentinel
+		encoder.nullSentinel = sentinel
+	}
+}
+
+// WithOmitDefaults create a option to omit the fields whose value equals the
+// value of their `default` tag, as the Decoder binds it back. The
+// `omitdefault` tag option does the same for a single field.
+func WithOmitDefaults() EncoderOption {
+	return func(encoder *Encoder) {
+		encoder.omitDefaults = true
+	}
+}
+
 // NewEncoder init new *Encoder instance
 // Use EncoderOption to apply options
 func NewEncoder(options ...EncoderOption) *Encoder {
 	e := &Encoder{
 		tagAlias: "query",
+		pathTag:  "path",
 	}
 
 	// Apply options
@@ -112,14 +199,13 @@ func (e *Encoder) Values(v interface{}) (url.Values, error) {
 	case reflect.Invalid:
 		return nil, errors.Errorf("expects struct input, got %v", val.Kind())
 	case reflect.Struct:
+		values := make(url.Values)
 		enc := e.dataPool.Get().(*encoder)
-		enc.values = make(url.Values)
-		err := enc.encodeStruct(val, enc.values, nil)
+		defer e.dataPool.Put(enc)
+		err := enc.encodeStruct(val, values, nil)
 		if err != nil {
 			return nil, err
 		}
-		values := enc.values
-		e.dataPool.Put(enc)
 		return values, nil
 	default:
 		return nil, errors.Errorf("expects struct input, got %v", val.Kind())
@@ -142,6 +228,7 @@ func (e *Encoder) Encode(v interface{}, values url.Values) error {
 		return errors.Errorf("expects struct input, got %v", val.Kind())
 	case reflect.Struct:
 		enc := e.dataPool.Get().(*encoder)
+		defer e.dataPool.Put(enc)
 		err := enc.encodeStruct(val, values, nil)
 		if err != nil {
 			return err
@@ -152,6 +239,41 @@ func (e *Encoder) Encode(v interface{}, values url.Values) error {
 	}
 }
 
+// EncodeValues encodes values into URL encoded form sorted by key like
+// url.Values.Encode, except that keys without values are written as bare
+// keys, `debug` in `debug&q=x`, where url.Values.Encode drops them.
+func EncodeValues(values url.Values) string {
+	if values == nil {
+		return ""
+	}
+	var buf strings.Builder
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		vs := values[k]
+		keyEscaped := url.QueryEscape(k)
+		if len(vs) == 0 {
+			if buf.Len() > 0 {
+				buf.WriteByte('&')
+			}
+			buf.WriteString(keyEscaped)
+			continue
+		}
+		for _, v := range vs {
+			if buf.Len() > 0 {
+				buf.WriteByte('&')
+			}
+			buf.WriteString(keyEscaped)
+			buf.WriteByte('=')
+			buf.WriteString(url.QueryEscape(v))
+		}
+	}
+	return buf.String()
+}
+
 func (e *encoder) encodeStruct(stVal reflect.Value, values url.Values, scope []byte) error {
 	stTyp := stVal.Type()
 
@@ -163,9 +285,46 @@ func (e *encoder) encodeStruct(stVal reflect.Value, values url.Values, scope []b
 		e.e.cache.Store(stTyp, cachedFlds)
 	}
 
+	target := values
+	result := func(name string, vals ...string) {
+		target[name] = append(target[name], vals...)
+		if e.names != nil {
+			e.names = append(e.names, name)
+		}
+	}
+
 	for i, cachedFld := range cachedFlds {
 		stFldVal := stVal.Field(i)
 
+		target = values
+		if field, ok := cachedFld.(*pathField); ok {
+			if e.pathValues == nil {
+				continue
+			}
+			target, cachedFld = e.pathValues, field.cachedField
+		}
+
+		if field, ok := cachedFld.(*defaultField); ok {
+			if field.isDefault(stFldVal) {
+				continue
+			}
+			cachedFld = field.cachedField
+		}
+		if field, ok := cachedFld.(*presenceField); ok {
+			elemVal, ok := field.elem(stFldVal)
+			if !ok {
+				continue
+			}
+			stFldVal, cachedFld = elemVal, field.cachedField
+		}
+		if field, ok := cachedFld.(*nullField); ok {
+			if !derefValue(stFldVal).IsValid() {
+				field.formatNil(result)
+				continue
+			}
+			cachedFld = field.cachedField
+		}
+
 		switch cachedFld := cachedFld.(type) {
 		case nil:
 			// skip field
@@ -202,7 +361,7 @@ func (e *encoder) encodeStruct(stVal reflect.Value, values url.Values, scope []b
 				}
 				if count := countElem(stFldVal); count > 0 {
 					// preallocate slice
-					values[cachedFld.name] = make([]string, 0, countElem(stFldVal))
+					target[cachedFld.name] = make([]string, 0, countElem(stFldVal))
 				} else {
 					continue
 				}
@@ -210,11 +369,9 @@ func (e *encoder) encodeStruct(stVal reflect.Value, values url.Values, scope []b
 		}
 
 		// format value
-		err := cachedFld.formatFnc(stFldVal, func(name string, val string) {
-			values[name] = append(values[name], val)
-		})
+		err := cachedFld.formatFnc(stFldVal, result)
 		if err != nil {
-			return err
+			return fieldError(err, "", stTyp.Field(i).Name, nil)
 		}
 	}
 	return nil
@@ -233,9 +390,15 @@ func (e *encoder) structCaching(fields *cachedFields, stVal reflect.Value, scope
 			continue
 		}
 
-		e.getTagNameAndOpts(structField)
+		isPath := e.e.isPathField(structField)
+		if isPath {
+			e.getTagNameAndOptsOf(structField, e.e.pathTag)
+		} else {
+			e.getTagNameAndOpts(structField)
+		}
 
 		if string(e.tags[0]) == "-" { // ignored field
+			*fields = append(*fields, nil)
 			continue
 		}
 
@@ -251,59 +414,100 @@ func (e *encoder) structCaching(fields *cachedFields, stVal reflect.Value, scope
 
 		fieldVal := stVal.Field(i)
 
-		if fieldVal.Type().Implements(encoderType) {
-			*fields = append(*fields, newCustomField(fieldVal.Type(), e.tags[0], e.tags[1:]))
+		// caching nested structs overwrites e.tags
+		tagName, tagOptions := copyTags(e.tags[0], e.tags[1:])
+
+		if fieldTyp := getType(fieldVal); isPresenceType(fieldTyp) {
+			elemVal := reflect.Zero(presenceElemType(fieldTyp))
+			field := e.e.nullable(e.newStructField(elemVal), elemVal.Type(), tagName, tagOptions)
+			*fields = append(*fields, e.e.pathField(&presenceField{cachedField: field}, isPath))
 			continue
 		}
 
-		fieldTyp := getType(fieldVal)
+		field := e.newStructField(fieldVal)
+		field = e.e.nullable(field, fieldVal.Type(), tagName, tagOptions)
+		*fields = append(*fields, e.e.pathField(field, isPath))
+	}
 
-		if fieldTyp == timeType {
-			*fields = append(*fields, newTimeField(e.tags[0], e.tags[1:]))
-			continue
-		}
+	e.e.cacheDefaults(*fields, structTyp)
+}
 
-		switch fieldTyp.Kind() {
-		case reflect.Struct:
-			fieldVal = reflect.Zero(fieldTyp)
-			// Clear and set new scope
-			e.scope = e.scope[:0]
-			e.scope = append(e.scope, e.tags[0]...)
-			// New embed field
-			field := newEmbedField(fieldVal.NumField(), e.tags[0], e.tags[1:])
-			*fields = append(*fields, field)
-			// Recursive
-			e.structCaching(&field.cachedFields, fieldVal, e.scope)
-		case reflect.Slice, reflect.Array:
-			//Slice element type
-			elemType := fieldTyp.Elem()
-			if elemType.Implements(encoderType) {
-				*fields = append(*fields, e.newListField(elemType, e.tags[0], e.tags[1:]))
-				continue
-			}
-			for elemType.Kind() == reflect.Ptr {
-				elemType = elemType.Elem()
-			}
-			*fields = append(*fields, e.newListField(elemType, e.tags[0], e.tags[1:]))
-		case reflect.Map:
-			keyType := fieldTyp.Key()
-			/*for keyType.Kind() == reflect.Ptr {
-				keyType = keyType.Elem()
-			}*/
-			valueType := fieldTyp.Elem()
-			/*for valueType.Kind() == reflect.Ptr {
-				valueType = valueType.Elem()
-			}*/
-			*fields = append(*fields, newMapField(keyType, valueType, e.tags[0], e.tags[1:]))
-		default:
-			*fields = append(*fields, newCachedFieldByKind(fieldTyp.Kind(), e.tags[0], e.tags[1:]))
+// newStructField returns the cachedField of the struct field fieldVal named
+// and tagged by e.tags.
+func (e *encoder) newStructField(fieldVal reflect.Value) cachedField {
+	if e.e.isCustomType(fieldVal.Type(), e.tags[1:]) {
+		return e.e.newCustomField(fieldVal.Type(), e.tags[0], e.tags[1:])
+	}
+
+	fieldTyp := getType(fieldVal)
+
+	if fieldTyp == timeType {
+		return e.e.newTimeField(e.tags[0], e.tags[1:])
+	}
+
+	switch fieldTyp.Kind() {
+	case reflect.Struct:
+		fieldVal = reflect.Zero(fieldTyp)
+		// Clear and set new scope
+		e.scope = e.scope[:0]
+		e.scope = append(e.scope, e.tags[0]...)
+		// New embed field
+		field := newEmbedField(fieldVal.NumField(), e.tags[0], e.tags[1:])
+		// Recursive
+		e.structCaching(&field.cachedFields, fieldVal, e.scope)
+		return field
+	case reflect.Slice, reflect.Array:
+		//Slice element type
+		elemType := fieldTyp.Elem()
+		if e.e.isCustomType(elemType, e.tags[1:]) {
+			return e.newListField(elemType, e.tags[0], e.tags[1:])
+		}
+		for elemType.Kind() == reflect.Ptr {
+			elemType = elemType.Elem()
 		}
+		return e.newListField(elemType, e.tags[0], e.tags[1:])
+	case reflect.Map:
+		keyType := fieldTyp.Key()
+		/*for keyType.Kind() == reflect.Ptr {
+			keyType = keyType.Elem()
+		}*/
+		valueType := fieldTyp.Elem()
+		/*for valueType.Kind() == reflect.Ptr {
+			valueType = valueType.Elem()
+		}*/
+		return e.e.newMapField(keyType, valueType, e.tags[0], e.tags[1:])
+	default:
+		return e.e.newCacheFieldByType(fieldTyp, e.tags[0], e.tags[1:])
+	}
+}
+
+// isPathField reports whether the struct field f is a path parameter, it has
+// a path tag and no query tag.
+func (e *Encoder) isPathField(f reflect.StructField) bool {
+	if _, ok := f.Tag.Lookup(e.tagAlias); ok {
+		return false
+	}
+	name, _ := parseTag(f.Tag.Get(e.pathTag))
+	return name != "" && name != "-"
+}
+
+// pathField wraps field when it is a path parameter.
+func (e *Encoder) pathField(field cachedField, isPath bool) cachedField {
+	if !isPath || field == nil {
+		return field
 	}
+	return &pathField{cachedField: field}
 }
 
 func (e *encoder) getTagNameAndOpts(f reflect.StructField) {
+	e.getTagNameAndOptsOf(f, e.e.tagAlias)
+}
+
+// getTagNameAndOptsOf reads the name and options of the tag alias of f into
+// e.tags.
+func (e *encoder) getTagNameAndOptsOf(f reflect.StructField, alias string) {
 	// Get tag by alias
-	tag := f.Tag.Get(e.e.tagAlias)
+	tag := f.Tag.Get(alias)
 
 	// Clear first tag in slice
 	e.tags[0] = e.tags[0][:0]
@@ -317,6 +521,9 @@ func (e *encoder) getTagNameAndOpts(f reflect.StructField) {
 		e.tags[0] = append(e.tags[0][:0], tag...)
 
 		splitTags := strings.Split(tag, ",")
+		for cap(e.tags) < len(splitTags) {
+			e.tags = append(e.tags[:cap(e.tags)], make([]byte, 0, 56))
+		}
 		e.tags = e.tags[:len(splitTags)]
 
 		for i := 0; i < len(splitTags); i++ {
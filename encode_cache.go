@@ -40,6 +40,8 @@ func newCacheStore() *cacheStore {
 
 // Retrieve cachedFields corresponding to reflect.Type
 func (cacheStore *cacheStore) Retrieve(typ reflect.Type) cachedFields {
+	cacheStore.mutex.RLock()
+	defer cacheStore.mutex.RUnlock()
 	return cacheStore.m[typ]
 }
 
@@ -53,7 +55,9 @@ func (cacheStore *cacheStore) Store(typ reflect.Type, cachedFields cachedFields)
 }
 
 type (
-	resultFunc func(name string, val string)
+	// resultFunc receives the values of a parameter, a parameter without
+	// values is a bare key like `debug` in `debug&q=x`
+	resultFunc func(name string, vals ...string)
 
 	// cachedField
 	cachedField interface {
@@ -63,13 +67,15 @@ type (
 	cachedFields []cachedField
 )
 
-func newCacheFieldByType(typ reflect.Type, tagName []byte, tagOptions [][]byte) cachedField {
-	if typ.Implements(encoderType) {
-		return newCustomField(typ, tagName, tagOptions)
+func (e *Encoder) newCacheFieldByType(typ reflect.Type, tagName []byte, tagOptions [][]byte) cachedField {
+	if e.isCustomType(typ, tagOptions) {
+		return e.newCustomField(typ, tagName, tagOptions)
 	}
-	switch typ {
-	case timeType:
-		return newTimeField(tagName, tagOptions)
+	switch {
+	case typ == timeType:
+		return e.newTimeField(tagName, tagOptions)
+	case typ.Kind() == reflect.Interface:
+		return e.newInterfaceField(tagName, tagOptions)
 	default:
 		return newCachedFieldByKind(typ.Kind(), tagName, tagOptions)
 	}
@@ -95,8 +101,6 @@ func newCachedFieldByKind(kind reflect.Kind, tagName []byte, tagOptions [][]byte
 		return newComplex128Field(tagName, tagOptions)
 	case reflect.Struct:
 		return newEmbedField(0, tagName, tagOptions)
-	case reflect.Interface:
-		return newInterfaceField(tagName, tagOptions)
 	default:
 		return nil
 	}
@@ -111,6 +115,13 @@ func getType(fieldVal reflect.Value) reflect.Type {
 	return stFieldTyp
 }
 
+func derefType(typ reflect.Type) reflect.Type {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	return typ
+}
+
 func countElem(value reflect.Value) int {
 	count := 0
 	for i := 0; i < value.Len(); i++ {
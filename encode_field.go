@@ -23,18 +23,23 @@
 package qs
 
 import (
+	"encoding"
+	"fmt"
 	"reflect"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
 type timeFormat uint8
 
 const (
-	_ timeFormat = iota
+	timeFormatLayout timeFormat = iota
 	timeFormatSecond
 	timeFormatMillis
+	timeFormatMicros
+	timeFormatNanos
 )
 
 type listFormat uint8
@@ -89,7 +94,7 @@ func (embedField *embedField) formatFnc(v reflect.Value, result resultFunc) erro
 		}
 		err := cachedField.formatFnc(v.Field(i), result)
 		if err != nil {
-			return err
+			return fieldError(err, "", v.Type().Field(i).Name, nil)
 		}
 	}
 	return nil
@@ -103,6 +108,12 @@ type listField struct {
 }
 
 func (listField *listField) formatFnc(field reflect.Value, result resultFunc) error {
+	for field.Kind() == reflect.Ptr {
+		if field.IsNil() {
+			return nil
+		}
+		field = field.Elem()
+	}
 	switch listField.arrayFormat {
 	case arrayFormatComma:
 		var str strings.Builder
@@ -124,14 +135,16 @@ func (listField *listField) formatFnc(field reflect.Value, result resultFunc) er
 					continue
 				}
 			}
-			err := listField.cachedField.formatFnc(elemVal, func(name string, val string) {
-				if i > 0 {
-					str.WriteByte(',')
+			err := listField.cachedField.formatFnc(elemVal, func(name string, vals ...string) {
+				for _, val := range vals {
+					if i > 0 {
+						str.WriteByte(',')
+					}
+					str.WriteString(val)
 				}
-				str.WriteString(val)
 			})
 			if err != nil {
-				return err
+				return fieldError(err, listField.name, elemPath(i), nil)
 			}
 		}
 		returnStr := str.String()
@@ -158,11 +171,11 @@ func (listField *listField) formatFnc(field reflect.Value, result resultFunc) er
 					continue
 				}
 			}
-			err := listField.cachedField.formatFnc(elemVal, func(name string, val string) {
-				result(listField.name, val)
+			err := listField.cachedField.formatFnc(elemVal, func(name string, vals ...string) {
+				result(listField.name, vals...)
 			})
 			if err != nil {
-				return err
+				return fieldError(err, listField.name, elemPath(i), nil)
 			}
 		}
 	case arrayFormatIndex:
@@ -186,7 +199,7 @@ func (listField *listField) formatFnc(field reflect.Value, result resultFunc) er
 				}
 			}
 			if v, ok := listField.cachedField.(*embedField); ok {
-				err := v.formatFnc(elemVal, func(name string, val string) {
+				err := v.formatFnc(elemVal, func(name string, vals ...string) {
 					var str strings.Builder
 					str.WriteString(listField.name)
 					str.WriteString(strconv.FormatInt(int64(i), 10))
@@ -194,30 +207,38 @@ func (listField *listField) formatFnc(field reflect.Value, result resultFunc) er
 					str.WriteByte('[')
 					str.WriteString(name)
 					str.WriteByte(']')
-					result(str.String(), val)
+					result(str.String(), vals...)
 					count++
 				})
+				if fieldErr, ok := err.(*FieldError); ok {
+					fieldErr.Key = listField.name + strconv.Itoa(i) + "][" + fieldErr.Key + "]"
+				}
 				if err != nil {
-					return err
+					return fieldError(err, "", elemPath(i), nil)
 				}
 				continue
 			}
-			err := listField.cachedField.formatFnc(elemVal, func(name string, val string) {
+			err := listField.cachedField.formatFnc(elemVal, func(name string, vals ...string) {
 				var key strings.Builder
 				key.WriteString(listField.name)
 				key.WriteString(strconv.FormatInt(int64(count), 10))
 				key.WriteString("]")
-				result(key.String(), val)
+				result(key.String(), vals...)
 				count++
 			})
 			if err != nil {
-				return err
+				return fieldError(err, listField.name+strconv.Itoa(count)+"]", elemPath(i), nil)
 			}
 		}
 	}
 	return nil
 }
 
+// elemPath returns the Go path of the list element at index i.
+func elemPath(i int) string {
+	return "[" + strconv.Itoa(i) + "]"
+}
+
 func (e *encoder) newListField(elemTyp reflect.Type, tagName []byte, tagOptions [][]byte) *listField {
 	removeIdx := -1
 	for i, tagOption := range tagOptions {
@@ -230,7 +251,7 @@ func (e *encoder) newListField(elemTyp reflect.Type, tagName []byte, tagOptions
 	}
 
 	listField := &listField{
-		cachedField: newCacheFieldByType(elemTyp, nil, tagOptions),
+		cachedField: e.e.newCacheFieldByType(elemTyp, nil, tagOptions),
 	}
 
 	for _, tagOption := range tagOptions {
@@ -278,25 +299,27 @@ func (mapField *mapField) formatFnc(field reflect.Value, result resultFunc) erro
 
 	for mapRange.Next() {
 		fieldName = fieldName[:len(mapField.name)]
-		err := mapField.cachedKeyField.formatFnc(mapRange.Key(), func(_ string, val string) {
-			fieldName = append(fieldName, '[')
-			fieldName = append(fieldName, val...)
-			fieldName = append(fieldName, ']')
+		err := mapField.cachedKeyField.formatFnc(mapRange.Key(), func(_ string, vals ...string) {
+			for _, val := range vals {
+				fieldName = append(fieldName, '[')
+				fieldName = append(fieldName, val...)
+				fieldName = append(fieldName, ']')
+			}
 		})
 		if err != nil {
-			return err
+			return fieldError(err, mapField.name, "", nil)
 		}
-		err = mapField.cachedValueField.formatFnc(mapRange.Value(), func(_ string, val string) {
-			result(string(fieldName), val)
+		err = mapField.cachedValueField.formatFnc(mapRange.Value(), func(_ string, vals ...string) {
+			result(string(fieldName), vals...)
 		})
 		if err != nil {
-			return err
+			return fieldError(err, string(fieldName), string(fieldName[len(mapField.name):]), nil)
 		}
 	}
 	return nil
 }
 
-func newMapField(keyType reflect.Type, valueType reflect.Type, tagName []byte, tagOptions [][]byte) *mapField {
+func (e *Encoder) newMapField(keyType reflect.Type, valueType reflect.Type, tagName []byte, tagOptions [][]byte) *mapField {
 	removeIdx := -1
 	for i, tagOption := range tagOptions {
 		if string(tagOption) == tagOmitEmpty {
@@ -307,24 +330,34 @@ func newMapField(keyType reflect.Type, valueType reflect.Type, tagName []byte, t
 		tagOptions = append(tagOptions[:removeIdx], tagOptions[removeIdx+1:]...)
 	}
 
-	if !keyType.Implements(encoderType) {
+	if !e.isCustomType(keyType, nil) {
 		for keyType.Kind() == reflect.Ptr {
 			keyType = keyType.Elem()
 		}
 	}
 
-	if !valueType.Implements(encoderType) {
+	if !e.isCustomType(valueType, tagOptions) {
 		for valueType.Kind() == reflect.Ptr {
 			valueType = valueType.Elem()
 		}
 	}
 
+	// Only time keys and values, and custom values, take the field's
+	// options, other types are formatted with their defaults
+	var keyOptions, valueOptions [][]byte
+	if keyType == timeType {
+		keyOptions = tagOptions
+	}
+	if valueType == timeType || e.isCustomType(valueType, tagOptions) {
+		valueOptions = tagOptions
+	}
+
 	field := &mapField{
 		baseField: &baseField{
 			name: string(tagName),
 		},
-		cachedKeyField:   newCacheFieldByType(keyType, nil, nil),
-		cachedValueField: newCacheFieldByType(valueType, nil, nil),
+		cachedKeyField:   e.newCacheFieldByType(keyType, nil, keyOptions),
+		cachedValueField: e.newCacheFieldByType(valueType, nil, valueOptions),
 	}
 	return field
 }
@@ -332,12 +365,14 @@ func newMapField(keyType reflect.Type, valueType reflect.Type, tagName []byte, t
 type boolField struct {
 	*baseField
 	useInt bool
+	// isFlag writes true as a bare key and omits false
+	isFlag bool
 }
 
 func (boolField *boolField) formatFnc(v reflect.Value, result resultFunc) error {
 	for v.Kind() == reflect.Ptr {
 		if v.IsNil() {
-			if !boolField.omitEmpty {
+			if !boolField.omitEmpty && !boolField.isFlag {
 				result(boolField.name, "")
 			}
 			return nil
@@ -345,6 +380,12 @@ func (boolField *boolField) formatFnc(v reflect.Value, result resultFunc) error
 		v = v.Elem()
 	}
 	b := v.Bool()
+	if boolField.isFlag {
+		if b {
+			result(boolField.name)
+		}
+		return nil
+	}
 	if !b && boolField.omitEmpty {
 		return nil
 	}
@@ -372,6 +413,8 @@ func newBoolField(tagName []byte, tagOptions [][]byte) *boolField {
 			field.omitEmpty = true
 		case "int":
 			field.useInt = true
+		case tagFlag:
+			field.isFlag = true
 		}
 	}
 	return field
@@ -636,13 +679,110 @@ func newComplex128Field(tagName []byte, tagOptions [][]byte) *complex128Field {
 	return field
 }
 
+// timeOptions holds the time tag options shared by the Encoder and the
+// Decoder: the unix precision or layout, and the zone values are written in.
+type timeOptions struct {
+	timeFormat timeFormat
+	layout     string
+	location   *time.Location
+}
+
+// apply sets a single tag option, options that don't concern time are
+// ignored. It fails when the zone of `tz=` can't be loaded.
+func (opts *timeOptions) apply(tagOption string) error {
+	switch {
+	case tagOption == "second":
+		opts.timeFormat = timeFormatSecond
+	case tagOption == "millis":
+		opts.timeFormat = timeFormatMillis
+	case tagOption == "micros":
+		opts.timeFormat = timeFormatMicros
+	case tagOption == "nanos":
+		opts.timeFormat = timeFormatNanos
+	case strings.HasPrefix(tagOption, "layout="):
+		opts.timeFormat = timeFormatLayout
+		opts.layout = strings.TrimPrefix(tagOption, "layout=")
+	case strings.HasPrefix(tagOption, "tz="):
+		loc, err := time.LoadLocation(strings.TrimPrefix(tagOption, "tz="))
+		if err != nil {
+			return err
+		}
+		opts.location = loc
+	}
+	return nil
+}
+
+func (opts timeOptions) format(t time.Time) string {
+	if opts.location != nil {
+		t = t.In(opts.location)
+	}
+	switch opts.timeFormat {
+	case timeFormatSecond:
+		return strconv.FormatInt(t.Unix(), 10)
+	case timeFormatMillis:
+		return strconv.FormatInt(t.UnixMilli(), 10)
+	case timeFormatMicros:
+		return strconv.FormatInt(t.UnixMicro(), 10)
+	case timeFormatNanos:
+		return strconv.FormatInt(t.UnixNano(), 10)
+	default:
+		layout := opts.layout
+		if layout == "" {
+			layout = time.RFC3339
+		}
+		return t.Format(layout)
+	}
+}
+
+// parse is the inverse of format. Values without zone information, unix
+// timestamps included, are read in the configured zone, or UTC.
+func (opts timeOptions) parse(value string) (time.Time, error) {
+	loc := opts.location
+	if loc == nil {
+		loc = time.UTC
+	}
+	if opts.timeFormat == timeFormatLayout {
+		layout := opts.layout
+		if layout == "" {
+			layout = time.RFC3339
+		}
+		t, err := time.ParseInLocation(layout, value, loc)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if opts.location != nil {
+			t = t.In(opts.location)
+		}
+		return t, nil
+	}
+	i, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	switch opts.timeFormat {
+	case timeFormatSecond:
+		return time.Unix(i, 0).In(loc), nil
+	case timeFormatMillis:
+		return time.UnixMilli(i).In(loc), nil
+	case timeFormatMicros:
+		return time.UnixMicro(i).In(loc), nil
+	default:
+		return time.Unix(0, i).In(loc), nil
+	}
+}
+
 // Time field
 type timeField struct {
 	*baseField
-	timeFormat timeFormat
+	timeOptions timeOptions
+	// err is the invalid time option found when the field was cached
+	err error
 }
 
 func (timeField *timeField) formatFnc(v reflect.Value, result resultFunc) error {
+	if timeField.err != nil {
+		return timeField.err
+	}
 	for v.Kind() == reflect.Ptr {
 		if v.IsNil() {
 			if !timeField.omitEmpty {
@@ -656,31 +796,24 @@ func (timeField *timeField) formatFnc(v reflect.Value, result resultFunc) error
 	if t.IsZero() && timeField.omitEmpty {
 		return nil
 	}
-	switch timeField.timeFormat {
-	case timeFormatSecond:
-		result(timeField.name, strconv.FormatInt(t.Unix(), 10))
-	case timeFormatMillis:
-		result(timeField.name, strconv.FormatInt(t.UnixNano()/1000000, 10))
-	default:
-		result(timeField.name, t.Format(time.RFC3339))
-	}
+	result(timeField.name, timeField.timeOptions.format(t))
 	return nil
 }
 
-func newTimeField(tagName []byte, tagOptions [][]byte) *timeField {
+func (e *Encoder) newTimeField(tagName []byte, tagOptions [][]byte) *timeField {
 	field := &timeField{
 		baseField: &baseField{
 			name: string(tagName),
 		},
+		timeOptions: e.timeOptions,
 	}
 	for _, tagOption := range tagOptions {
-		switch string(tagOption) {
-		case tagOmitEmpty:
+		if string(tagOption) == tagOmitEmpty {
 			field.omitEmpty = true
-		case "second":
-			field.timeFormat = timeFormatSecond
-		case "millis":
-			field.timeFormat = timeFormatMillis
+			continue
+		}
+		if err := field.timeOptions.apply(string(tagOption)); err != nil && field.err == nil {
+			field.err = fmt.Errorf("invalid time option of field %s: %w", field.name, err)
 		}
 	}
 	return field
@@ -701,12 +834,16 @@ type QueryParamEncoder interface {
 type customField struct {
 	*baseField
 	isZeroer bool
+	// addressable encodes through a pointer to a copy of the value, for
+	// methods with a pointer receiver
+	addressable bool
+	encode      func(value interface{}) (string, error)
 }
 
 func (customField *customField) formatFnc(v reflect.Value, result resultFunc) error {
 	elem := v
 	for elem.Kind() == reflect.Ptr {
-		elem = v.Elem()
+		elem = elem.Elem()
 	}
 	if !elem.IsValid() {
 		if !customField.omitEmpty {
@@ -715,21 +852,30 @@ func (customField *customField) formatFnc(v reflect.Value, result resultFunc) er
 		return nil
 	}
 	valueInterface := v.Interface()
+	if customField.addressable {
+		ptr := reflect.New(elem.Type())
+		ptr.Elem().Set(elem)
+		valueInterface = ptr.Interface()
+	}
 	if customField.isZeroer && valueInterface.(Zeroer).IsZero() {
 		if !customField.omitEmpty {
 			result(customField.name, "")
 		}
 		return nil
 	}
-	str, err := valueInterface.(QueryParamEncoder).EncodeParam()
+	str, err := customField.encode(valueInterface)
 	if err != nil {
-		return err
+		return &FieldError{Key: customField.name, Err: err}
 	}
 	result(customField.name, str)
 	return nil
 }
 
-func newCustomField(typ reflect.Type, tagName []byte, tagOptions [][]byte) *customField {
+// newCustomField creates a field for a registered type or a type that encodes
+// itself. Registered types take precedence over EncodeParam, EncodeParam over
+// String, which is only used with the `stringer` option, and String over
+// MarshalText.
+func (e *Encoder) newCustomField(typ reflect.Type, tagName []byte, tagOptions [][]byte) *customField {
 	field := &customField{
 		baseField: &baseField{
 			name:      string(tagName),
@@ -741,6 +887,24 @@ func newCustomField(typ reflect.Type, tagName []byte, tagOptions [][]byte) *cust
 		field.isZeroer = true
 	}
 
+	switch registered, encode := e.customType(typ); {
+	case encode != nil:
+		field.encode = func(value interface{}) (string, error) {
+			v := reflect.ValueOf(value)
+			for v.Type() != registered {
+				v = v.Elem()
+			}
+			return encode(v.Interface())
+		}
+	case typ.Implements(encoderType):
+		field.encode = encodeParam
+	case hasTagOption(tagOptions, tagStringer) && typ.Implements(stringerType):
+		field.encode = encodeString
+	default:
+		field.encode = encodeText
+		field.addressable = !typ.Implements(textMarshalerType)
+	}
+
 	for _, tagOption := range tagOptions {
 		if string(tagOption) == tagOmitEmpty {
 			field.omitEmpty = true
@@ -749,18 +913,184 @@ func newCustomField(typ reflect.Type, tagName []byte, tagOptions [][]byte) *cust
 	return field
 }
 
+func encodeParam(value interface{}) (string, error) {
+	return value.(QueryParamEncoder).EncodeParam()
+}
+
+func encodeString(value interface{}) (string, error) {
+	return value.(fmt.Stringer).String(), nil
+}
+
+func encodeText(value interface{}) (string, error) {
+	text, err := value.(encoding.TextMarshaler).MarshalText()
+	return string(text), err
+}
+
+// customType returns the type registered with WithCustomType that typ is or
+// points to, and its encode func.
+func (e *Encoder) customType(typ reflect.Type) (reflect.Type, func(value interface{}) (string, error)) {
+	for {
+		if encode, ok := e.customTypes[typ]; ok {
+			return typ, encode
+		}
+		if typ.Kind() != reflect.Ptr {
+			return nil, nil
+		}
+		typ = typ.Elem()
+	}
+}
+
+// isCustomType reports whether typ is registered with WithCustomType or
+// encodes itself.
+func (e *Encoder) isCustomType(typ reflect.Type, tagOptions [][]byte) bool {
+	if _, encode := e.customType(typ); encode != nil {
+		return true
+	}
+	return isCustomType(typ, tagOptions)
+}
+
+// isCustomType reports whether typ encodes itself through QueryParamEncoder,
+// fmt.Stringer with the `stringer` option or encoding.TextMarshaler.
+// time.Time is left to timeField so that the time options apply.
+func isCustomType(typ reflect.Type, tagOptions [][]byte) bool {
+	if typ.Implements(encoderType) {
+		return true
+	}
+	if hasTagOption(tagOptions, tagStringer) && typ.Implements(stringerType) {
+		return true
+	}
+	return derefType(typ) != timeType && implementsTextMarshaler(typ)
+}
+
+// implementsTextMarshaler reports whether typ or a pointer to it, like
+// big.Int, implements encoding.TextMarshaler.
+func implementsTextMarshaler(typ reflect.Type) bool {
+	return typ.Implements(textMarshalerType) ||
+		typ.Kind() != reflect.Ptr && reflect.PointerTo(typ).Implements(textMarshalerType)
+}
+
+func hasTagOption(tagOptions [][]byte, option string) bool {
+	for _, tagOption := range tagOptions {
+		if string(tagOption) == option {
+			return true
+		}
+	}
+	return false
+}
+
+// defaultField omits a field whose value equals its `default` tag
+type defaultField struct {
+	cachedField
+	value reflect.Value
+}
+
+func (defaultField *defaultField) isDefault(v reflect.Value) bool {
+	return equalDefault(v, defaultField.value)
+}
+
+func (defaultField *defaultField) formatFnc(v reflect.Value, result resultFunc) error {
+	if defaultField.isDefault(v) {
+		return nil
+	}
+	return defaultField.cachedField.formatFnc(v, result)
+}
+
+// presenceField encodes the value of a Field, it omits an unset Field
+type presenceField struct {
+	cachedField
+}
+
+// elem returns the value of the Field v, following pointers, and whether it
+// is set.
+func (presenceField *presenceField) elem(v reflect.Value) (reflect.Value, bool) {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return reflect.Value{}, false
+		}
+		v = v.Elem()
+	}
+	field := presenceOf(v)
+	return field.elem(), field.IsSet()
+}
+
+func (presenceField *presenceField) formatFnc(v reflect.Value, result resultFunc) error {
+	elemVal, ok := presenceField.elem(v)
+	if !ok || presenceField.cachedField == nil {
+		return nil
+	}
+	return presenceField.cachedField.formatFnc(elemVal, result)
+}
+
+// nullField encodes a nil pointer following the null policy
+type nullField struct {
+	cachedField
+	name     string
+	policy   NullPolicy
+	sentinel string
+}
+
+func (nullField *nullField) formatFnc(v reflect.Value, result resultFunc) error {
+	if derefValue(v).IsValid() {
+		return nullField.cachedField.formatFnc(v, result)
+	}
+	nullField.formatNil(result)
+	return nil
+}
+
+func (nullField *nullField) formatNil(result resultFunc) {
+	switch nullField.policy {
+	case NullBareKey:
+		result(nullField.name)
+	case NullSentinel:
+		result(nullField.name, nullField.sentinel)
+	}
+}
+
+// pathField holds a field with a `path` tag and no query tag. It is a path
+// parameter of Encoder.URL, never a query parameter.
+type pathField struct {
+	cachedField
+}
+
+func (pathField *pathField) formatFnc(v reflect.Value, result resultFunc) error {
+	return nil
+}
+
 type interfaceField struct {
 	*baseField
+	encoder    *Encoder
 	tagName    []byte
 	tagOptions [][]byte
 	fieldMap   map[reflect.Type]cachedField
+	mutex      sync.RWMutex
+}
+
+// field returns the cachedField of the dynamic type typ, caching it on first
+// use. Interface fields are shared by every encoding of the struct, so the
+// cache is guarded.
+func (interfaceField *interfaceField) field(typ reflect.Type) cachedField {
+	interfaceField.mutex.RLock()
+	field, ok := interfaceField.fieldMap[typ]
+	interfaceField.mutex.RUnlock()
+	if ok {
+		return field
+	}
+
+	interfaceField.mutex.Lock()
+	defer interfaceField.mutex.Unlock()
+	if field, ok := interfaceField.fieldMap[typ]; ok {
+		return field
+	}
+	field = interfaceField.encoder.newCacheFieldByType(typ, interfaceField.tagName, interfaceField.tagOptions)
+	interfaceField.fieldMap[typ] = field
+	return field
 }
 
 func (interfaceField *interfaceField) formatFnc(v reflect.Value, result resultFunc) error {
 
 	v = v.Elem()
 
-	if v.IsValid() && v.Type().Implements(encoderType) {
+	if v.IsValid() && interfaceField.encoder.isCustomType(v.Type(), interfaceField.tagOptions) {
 		elem := v
 		for elem.Kind() == reflect.Ptr {
 			elem = elem.Elem()
@@ -781,10 +1111,7 @@ func (interfaceField *interfaceField) formatFnc(v reflect.Value, result resultFu
 		}
 	}
 
-	if field := interfaceField.fieldMap[v.Type()]; field == nil {
-		interfaceField.fieldMap[v.Type()] = newCacheFieldByType(v.Type(), interfaceField.tagName, interfaceField.tagOptions)
-	}
-	if field := interfaceField.fieldMap[v.Type()]; field != nil {
+	if field := interfaceField.field(v.Type()); field != nil {
 		err := field.formatFnc(v, result)
 		if err != nil {
 			return err
@@ -793,16 +1120,25 @@ func (interfaceField *interfaceField) formatFnc(v reflect.Value, result resultFu
 	return nil
 }
 
-func newInterfaceField(tagName []byte, tagOptions [][]byte) *interfaceField {
-	copiedTagName := make([]byte, len(tagName))
-	copy(copiedTagName, tagName)
+// copyTags returns copies of the tag name and options, which point into the
+// buffers of a pooled encoder that later encodings overwrite.
+func copyTags(tagName []byte, tagOptions [][]byte) ([]byte, [][]byte) {
+	copiedTagName := append([]byte(nil), tagName...)
 	copiedTagOptions := make([][]byte, len(tagOptions))
-	copy(copiedTagOptions, tagOptions)
+	for i, tagOption := range tagOptions {
+		copiedTagOptions[i] = append([]byte(nil), tagOption...)
+	}
+	return copiedTagName, copiedTagOptions
+}
+
+func (e *Encoder) newInterfaceField(tagName []byte, tagOptions [][]byte) *interfaceField {
+	copiedTagName, copiedTagOptions := copyTags(tagName, tagOptions)
 
 	field := &interfaceField{
 		baseField: &baseField{
 			name: string(copiedTagName),
 		},
+		encoder:    e,
 		tagName:    copiedTagName,
 		tagOptions: copiedTagOptions,
 		fieldMap:   make(map[reflect.Type]cachedField, 5),
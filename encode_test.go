@@ -24,9 +24,12 @@ package qs
 
 import (
 	"fmt"
+	"math/big"
+	"net/netip"
 	"net/url"
 	"reflect"
 	"strconv"
+	"sync"
 	"testing"
 	"time"
 
@@ -130,6 +133,17 @@ func TestIgnore(t *testing.T) {
 		return
 	}
 	assert.Equal(t, url.Values{}, values)
+
+	v2 := struct {
+		Ignored int       `query:"-"`
+		Time    time.Time `query:"time,second"`
+	}{
+		Time: time.Unix(600, 0),
+	}
+
+	values, err = encoder.Values(v2)
+	test.NoError(err)
+	test.Equal(url.Values{"time": []string{"600"}}, values)
 }
 
 func TestWithTagAlias(t *testing.T) {
@@ -595,6 +609,84 @@ func TestTimeFormat(t *testing.T) {
 	assert.Equal(t, expected, values)
 }
 
+func TestTimeLayoutAndZone(t *testing.T) {
+	test := assert.New(t)
+
+	tm := time.Date(2020, 2, 2, 23, 30, 0, 123456789, time.UTC)
+
+	times := struct {
+		Date      time.Time            `query:"date,layout=2006-01-02"`
+		Zoned     time.Time            `query:"zoned,tz=Asia/Ho_Chi_Minh"`
+		Micros    time.Time            `query:"micros,micros"`
+		Nanos     *time.Time           `query:"nanos,nanos"`
+		DateList  []time.Time          `query:"date_list,comma,layout=2006-01-02"`
+		DateMap   map[string]time.Time `query:"date_map,layout=2006-01-02"`
+		ManyOpts  []time.Time          `query:"many,index,omitempty,layout=2006-01-02,tz=UTC,second,micros"`
+		SecondMap map[string]time.Time `query:"second_map,second"`
+	}{
+		Date:      tm,
+		Zoned:     tm,
+		Micros:    tm,
+		Nanos:     &tm,
+		DateList:  []time.Time{tm, tm.AddDate(0, 0, 1)},
+		DateMap:   map[string]time.Time{"from": tm},
+		ManyOpts:  []time.Time{tm},
+		SecondMap: map[string]time.Time{"to": tm},
+	}
+	values, err := NewEncoder().Values(times)
+	test.NoError(err)
+
+	expected := url.Values{
+		"date":           []string{"2020-02-02"},
+		"zoned":          []string{"2020-02-03T06:30:00+07:00"},
+		"micros":         []string{"1580686200123456"},
+		"nanos":          []string{"1580686200123456789"},
+		"date_list":      []string{"2020-02-02,2020-02-03"},
+		"date_map[from]": []string{"2020-02-02"},
+		"many[0]":        []string{"1580686200123456"},
+		"second_map[to]": []string{"1580686200"},
+	}
+	test.Equal(expected, values)
+}
+
+func TestWithTimeLayoutAndLocation(t *testing.T) {
+	test := assert.New(t)
+
+	loc := time.FixedZone("UTC+7", 7*60*60)
+	encoder := NewEncoder(WithTimeLayout("2006-01-02 15:04"), WithTimeLocation(loc))
+
+	tm := time.Date(2020, 2, 2, 23, 30, 0, 0, time.UTC)
+	times := struct {
+		Default time.Time `query:"default"`
+		Second  time.Time `query:"second,second"`
+		Layout  time.Time `query:"layout,layout=2006-01-02"`
+		Zone    time.Time `query:"zone,tz=UTC"`
+	}{tm, tm, tm, tm}
+
+	values, err := encoder.Values(times)
+	test.NoError(err)
+
+	expected := url.Values{
+		"default": []string{"2020-02-03 06:30"},
+		"second":  []string{"1580686200"},
+		"layout":  []string{"2020-02-03"},
+		"zone":    []string{"2020-02-02 23:30"},
+	}
+	test.Equal(expected, values)
+}
+
+func TestEncodeInvalidTimeZone(t *testing.T) {
+	test := assert.New(t)
+
+	s := struct {
+		Zone time.Time `query:"zone,tz=Nowhere/Invalid"`
+	}{time.Now()}
+
+	_, err := NewEncoder().Values(s)
+	test.Error(err)
+	test.Contains(err.Error(), "zone")
+}
+
 func TestBoolFormat(t *testing.T) {
 	test := assert.New(t)
 	encoder := NewEncoder()
@@ -809,6 +901,30 @@ func TestEncodeInterface(t *testing.T) {
 	test.Equal(expected, values)
 }
 
+func TestEncodeInterfaceTagOptions(t *testing.T) {
+	test := assert.New(t)
+	encoder := NewEncoder()
+
+	type query struct {
+		X interface{} `query:"x,second"`
+	}
+	type other struct {
+		Time time.Time `query:"time,millis"`
+	}
+
+	values, err := encoder.Values(query{X: 1})
+	test.NoError(err)
+	test.Equal("1", values.Get("x"))
+
+	// the options of the interface field outlive the pooled encoder buffers
+	_, err = encoder.Values(other{Time: time.Unix(5, 0)})
+	test.NoError(err)
+
+	values, err = encoder.Values(query{X: time.Unix(5, 0)})
+	test.NoError(err)
+	test.Equal("5", values.Get("x"))
+}
+
 func TestEncodeMap(t *testing.T) {
 	test := assert.New(t)
 	encoder := NewEncoder()
@@ -1084,6 +1200,192 @@ func TestEncodeErrCustomType(t *testing.T) {
 	test.Error(err)
 }
 
+type level int
+
+func (l level) String() string {
+	return [...]string{"debug", "info"}[l]
+}
+
+type errText struct{}
+
+func (errText) MarshalText() ([]byte, error) {
+	return nil, fmt.Errorf("failed to marshal text")
+}
+
+func TestEncodeTextMarshaler(t *testing.T) {
+	test := assert.New(t)
+	encoder := NewEncoder()
+
+	addr := netip.MustParseAddr("10.0.0.1")
+
+	s := struct {
+		Addr      netip.Addr            `query:"addr"`
+		AddrPtr   *netip.Addr           `query:"addr_ptr"`
+		NilAddr   *netip.Addr           `query:"nil_addr,omitempty"`
+		Big       *big.Int              `query:"big"`
+		BigVal    big.Int               `query:"big_val"`
+		BigVals   []big.Int             `query:"big_vals,comma"`
+		BigMap    map[string]big.Int    `query:"big_map"`
+		Addrs     []netip.Addr          `query:"addrs,comma"`
+		AddrPtrs  []*netip.Addr         `query:"addr_ptrs,index"`
+		AddrKeys  map[netip.Addr]string `query:"addr_keys"`
+		AddrVals  map[string]*big.Int   `query:"addr_vals"`
+		Level     level                 `query:"level,stringer"`
+		NoOpt     level                 `query:"no_opt"`
+		Levels    []level               `query:"levels,stringer"`
+		Interface interface{}           `query:"interface"`
+		Time      time.Time             `query:"time,second"`
+	}{
+		Addr:      addr,
+		AddrPtr:   &addr,
+		Big:       big.NewInt(42),
+		BigVal:    *big.NewInt(43),
+		BigVals:   []big.Int{*big.NewInt(1), *big.NewInt(2)},
+		BigMap:    map[string]big.Int{"n": *big.NewInt(8)},
+		Addrs:     []netip.Addr{addr, addr.Next()},
+		AddrPtrs:  []*netip.Addr{nil, &addr},
+		AddrKeys:  map[netip.Addr]string{addr: "gateway"},
+		AddrVals:  map[string]*big.Int{"n": big.NewInt(7)},
+		Level:     1,
+		NoOpt:     1,
+		Levels:    []level{0, 1},
+		Interface: &addr,
+		Time:      time.Unix(600, 0),
+	}
+
+	values, err := encoder.Values(&s)
+	test.NoError(err)
+
+	expected := url.Values{
+		"addr":                []string{"10.0.0.1"},
+		"addr_ptr":            []string{"10.0.0.1"},
+		"big":                 []string{"42"},
+		"big_val":             []string{"43"},
+		"big_vals":            []string{"1,2"},
+		"big_map[n]":          []string{"8"},
+		"addrs":               []string{"10.0.0.1,10.0.0.2"},
+		"addr_ptrs[0]":        []string{"10.0.0.1"},
+		"addr_keys[10.0.0.1]": []string{"gateway"},
+		"addr_vals[n]":        []string{"7"},
+		"level":               []string{"info"},
+		"no_opt":              []string{"1"},
+		"levels":              []string{"debug", "info"},
+		"interface":           []string{"10.0.0.1"},
+		"time":                []string{"600"},
+	}
+	test.Equal(expected, values)
+
+	_, err = encoder.Values(struct {
+		Err errText `query:"err"`
+	}{})
+	test.Error(err)
+}
+
+// coord stands for a third-party type that implements none of the encoding
+// interfaces
+type coord struct {
+	Lat float64
+	Lng float64
+}
+
+func encodeCoord(value interface{}) (string, error) {
+	c := value.(coord)
+	if c.Lat > 90 || c.Lat < -90 {
+		return "", fmt.Errorf("latitude %g out of range", c.Lat)
+	}
+	return fmt.Sprintf("%g,%g", c.Lat, c.Lng), nil
+}
+
+func TestEncodeWithCustomType(t *testing.T) {
+	test := assert.New(t)
+	encoder := NewEncoder(
+		WithCustomType(reflect.TypeOf(coord{}), encodeCoord),
+		WithCustomType(timeType, func(value interface{}) (string, error) {
+			return value.(time.Time).UTC().Format("2006-01-02"), nil
+		}),
+	)
+
+	home := coord{Lat: 48.85, Lng: 2.35}
+	s := struct {
+		Coord    coord            `query:"coord"`
+		CoordPtr *coord           `query:"coord_ptr"`
+		NilCoord *coord           `query:"nil_coord,omitempty"`
+		Coords   []coord          `query:"coords,bracket"`
+		Keys     map[coord]string `query:"keys"`
+		Vals     map[string]coord `query:"vals"`
+		Time     time.Time        `query:"time,second"`
+	}{
+		Coord:    home,
+		CoordPtr: &home,
+		Coords:   []coord{home, {Lat: 1, Lng: 2}},
+		Keys:     map[coord]string{home: "home"},
+		Vals:     map[string]coord{"home": home},
+		Time:     time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+
+	values, err := encoder.Values(&s)
+	test.NoError(err)
+	test.Equal(url.Values{
+		"coord":            []string{"48.85,2.35"},
+		"coord_ptr":        []string{"48.85,2.35"},
+		"coords[]":         []string{"48.85,2.35", "1,2"},
+		"keys[48.85,2.35]": []string{"home"},
+		"vals[home]":       []string{"48.85,2.35"},
+		"time":             []string{"2020-01-02"},
+	}, values)
+
+	_, err = encoder.Values(struct {
+		Coord coord `query:"coord"`
+	}{Coord: coord{Lat: 100}})
+	test.Error(err)
+}
+
+func TestEncodeConcurrently(t *testing.T) {
+	test := assert.New(t)
+	encoder := NewEncoder()
+
+	tm := time.Unix(600, 0).UTC()
+
+	type Nested struct {
+		Time time.Time `query:"time,second"`
+	}
+
+	type query struct {
+		Any    interface{}    `query:"any"`
+		Nested Nested         `query:"nested"`
+		List   []Nested       `query:"list,index"`
+		Map    map[string]int `query:"map"`
+	}
+
+	inputs := []interface{}{"abc", 5, true, &tm, Timestamp{tm}, withFloat64(1.5)}
+	expected := []string{"abc", "5", "true", "1970-01-01T00:10:00Z", "1970-01-01T00:10:00Z", "1.5"}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 64; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			q := query{
+				Any:    inputs[i%len(inputs)],
+				Nested: Nested{Time: tm},
+				List:   []Nested{{Time: tm}},
+				Map:    map[string]int{"a": i},
+			}
+			values, err := encoder.Values(&q)
+			test.NoError(err)
+			test.Equal(expected[i%len(inputs)], values.Get("any"))
+			test.Equal("600", values.Get("nested[time]"))
+			test.Equal("600", values.Get("list[0][time]"))
+			test.Equal(strconv.Itoa(i), values.Get("map[a]"))
+
+			values = url.Values{}
+			test.NoError(encoder.Encode(&q, values))
+			test.Equal(expected[i%len(inputs)], values.Get("any"))
+		}(i)
+	}
+	wg.Wait()
+}
+
 func TestEncoderIgnoreUnregisterType(t *testing.T) {
 	test := assert.New(t)
 	encoder := NewEncoder()
@@ -0,0 +1,279 @@
+package qs
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrUnknownType is the cause of a FieldError for a field whose type can't be
+// decoded from a parameter.
+var ErrUnknownType = errors.New("unknown type")
+
+// ErrDuplicateParam is the cause of a FieldError for a parameter repeated for
+// a single-valued field whose duplicate policy is DuplicateError.
+var ErrDuplicateParam = errors.New("duplicate parameter")
+
+// FieldError describes a parameter that can't be bound to a field, or a field
+// that can't be encoded into a parameter. It wraps the error of the
+// conversion, so errors.Is and errors.As see through it, e.g. to
+// strconv.ErrSyntax or to the error of an UnmarshalParam method.
+type FieldError struct {
+	// Key is the parameter name, e.g. `user[from]` or `ids[2]`
+	Key string
+	// Field is the path of the Go field, e.g. `User.From` or `Items[0].Name`
+	Field string
+	// Value is the raw parameter value, it is empty when encoding
+	Value string
+	// Err is the cause of the failure
+	Err error
+}
+
+func (e *FieldError) Error() string {
+	var b strings.Builder
+	b.WriteString("qs: parameter ")
+	b.WriteString(strconv.Quote(e.Key))
+	if e.Field != "" {
+		b.WriteString(" (field ")
+		b.WriteString(e.Field)
+		b.WriteByte(')')
+	}
+	if e.Value != "" {
+		b.WriteString(": invalid value ")
+		b.WriteString(strconv.Quote(e.Value))
+	}
+	if e.Err != nil {
+		b.WriteString(": ")
+		b.WriteString(e.Err.Error())
+	}
+	return b.String()
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// UnknownParamError is the cause of the FieldError reported for a query
+// parameter that doesn't bind to any field, by a Decoder created
+// WithDisallowUnknownParams.
+type UnknownParamError struct {
+	// Suggestion is the known parameter closest to the unknown one, it is
+	// empty when none is close enough
+	Suggestion string
+}
+
+func (e *UnknownParamError) Error() string {
+	if e.Suggestion == "" {
+		return "unknown parameter"
+	}
+	return "unknown parameter, did you mean " + strconv.Quote(e.Suggestion) + "?"
+}
+
+// ErrLimitExceeded matches every LimitError with errors.Is.
+var ErrLimitExceeded = errors.New("limit exceeded")
+
+// Limits of a Decoder reported by LimitError
+const (
+	LimitParams      = "params"
+	LimitValueLength = "value length"
+	LimitDepth       = "depth"
+	LimitListLength  = "list length"
+	LimitMapEntries  = "map entries"
+)
+
+// LimitError reports an input exceeding one of the limits of a Decoder. It is
+// returned as is for the number of parameters, and as the cause of a
+// FieldError for the other limits.
+type LimitError struct {
+	// Limit is one of LimitParams, LimitValueLength, LimitDepth,
+	// LimitListLength or LimitMapEntries
+	Limit string
+	// Max is the value of the limit
+	Max int
+}
+
+func (e *LimitError) Error() string {
+	return "qs: " + e.Limit + " limit of " + strconv.Itoa(e.Max) + " exceeded"
+}
+
+// Is reports whether target is ErrLimitExceeded.
+func (e *LimitError) Is(target error) bool {
+	return target == ErrLimitExceeded
+}
+
+// FieldErrors lists every failing parameter of a binding made by a Decoder
+// created WithCollectErrors. Path parameters come before query parameters,
+// each in the order of the struct fields whether they failed to bind or to
+// validate, map entries in the order of their keys. Unknown query parameters
+// come first, sorted by name.
+type FieldErrors []*FieldError
+
+func (errs FieldErrors) Error() string {
+	var b strings.Builder
+	for i, err := range errs {
+		if i > 0 {
+			b.WriteString("; ")
+		}
+		b.WriteString(err.Error())
+	}
+	return b.String()
+}
+
+// Unwrap returns the FieldErrors so that errors.Is and errors.As look into
+// each of them.
+func (errs FieldErrors) Unwrap() []error {
+	unwrapped := make([]error, len(errs))
+	for i, err := range errs {
+		unwrapped[i] = err
+	}
+	return unwrapped
+}
+
+// joinFieldErrors returns the FieldErrors of both bindings, or the first
+// error that isn't a FieldErrors.
+func joinFieldErrors(first error, second error) error {
+	if first == nil {
+		return second
+	}
+	if second == nil {
+		return first
+	}
+	firstErrs, ok := first.(FieldErrors)
+	if !ok {
+		return first
+	}
+	secondErrs, ok := second.(FieldErrors)
+	if !ok {
+		return second
+	}
+	return append(firstErrs, secondErrs...)
+}
+
+// sortFieldErrors orders errs, the binding and validation failures of a
+// struct of type typ, by the position of their field in the struct, list
+// elements by index and map entries by key. Errors without a field, like
+// unknown parameters, keep their order in front.
+func sortFieldErrors(typ reflect.Type, errs FieldErrors) {
+	positions := make(map[*FieldError][]fieldStep, len(errs))
+	for _, err := range errs {
+		positions[err] = fieldPosition(typ, err.Field)
+	}
+	sort.SliceStable(errs, func(i, j int) bool {
+		return lessFieldPosition(positions[errs[i]], positions[errs[j]])
+	})
+}
+
+// fieldStep is a step of the path to a field: the index of a struct field or
+// of a list element, or the key of a map entry.
+type fieldStep struct {
+	index int
+	key   string
+}
+
+// fieldPosition resolves path, a Go path like `Items[1].Count` reported by
+// FieldErrors, to the steps leading to the field from a struct of type typ.
+// Resolving stops at the first step that isn't found.
+func fieldPosition(typ reflect.Type, path string) []fieldStep {
+	var steps []fieldStep
+	for path != "" {
+		typ = derefType(typ)
+		switch path[0] {
+		case '.':
+			path = path[1:]
+		case '[':
+			end := strings.IndexByte(path, ']')
+			if end < 0 {
+				return steps
+			}
+			key := path[1:end]
+			path = path[end+1:]
+			switch typ.Kind() {
+			case reflect.Map:
+				steps = append(steps, fieldStep{key: key})
+			case reflect.Slice, reflect.Array:
+				index, _ := strconv.Atoi(key)
+				steps = append(steps, fieldStep{index: index})
+			default:
+				return steps
+			}
+			typ = typ.Elem()
+		default:
+			end := strings.IndexAny(path, ".[")
+			if end < 0 {
+				end = len(path)
+			}
+			if typ.Kind() != reflect.Struct {
+				return steps
+			}
+			field, ok := typ.FieldByName(path[:end])
+			if !ok {
+				return steps
+			}
+			path = path[end:]
+			for _, index := range field.Index {
+				steps = append(steps, fieldStep{index: index})
+			}
+			typ = field.Type
+		}
+	}
+	return steps
+}
+
+// lessFieldPosition reports whether the field at a comes before the field at
+// b, a field comes before the fields it contains.
+func lessFieldPosition(a []fieldStep, b []fieldStep) bool {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i].index != b[i].index {
+			return a[i].index < b[i].index
+		}
+		if a[i].key != b[i].key {
+			return a[i].key < b[i].key
+		}
+	}
+	return len(a) < len(b)
+}
+
+// valueError returns err, the failure to convert value, as a FieldError that
+// doesn't know its parameter and field yet.
+func valueError(value string, err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := err.(*FieldError); ok {
+		return err
+	}
+	return &FieldError{Value: value, Err: err}
+}
+
+// fieldError completes err, the failure to bind values or to encode a value,
+// with the parameter key and the Go field path. The key and the value known by
+// a FieldError returned from deeper down take precedence, its field path is
+// appended to path.
+func fieldError(err error, key string, path string, values []string) error {
+	fieldErr, ok := err.(*FieldError)
+	if !ok {
+		fieldErr = &FieldError{Value: strings.Join(values, ","), Err: err}
+	}
+	if fieldErr.Key == "" {
+		fieldErr.Key = key
+	}
+	fieldErr.Field = joinFieldPath(path, fieldErr.Field)
+	return fieldErr
+}
+
+// joinFieldPath appends the Go field path field to path, an index or map key
+// path like `[0]` is appended without a dot.
+func joinFieldPath(path string, field string) string {
+	switch {
+	case path == "":
+		return field
+	case field == "":
+		return path
+	case field[0] == '[':
+		return path + field
+	default:
+		return path + "." + field
+	}
+}
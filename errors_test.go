@@ -0,0 +1,156 @@
+package qs
+
+import (
+	"errors"
+	"net/netip"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeFieldError(t *testing.T) {
+	test := assert.New(t)
+
+	type item struct {
+		Count int `query:"count"`
+	}
+	type query struct {
+		Limit  int            `query:"limit"`
+		IDs    []uint         `query:"ids,comma"`
+		Items  []item         `query:"items"`
+		Filter map[string]int `query:"filter"`
+		Keys   map[int]string `query:"keys"`
+		Addr   netip.Addr     `query:"addr"`
+	}
+	type user struct {
+		Age int8 `query:"age"`
+	}
+	type nested struct {
+		User user `query:"user"`
+	}
+
+	tests := []struct {
+		uri   string
+		dest  any
+		key   string
+		field string
+		value string
+	}{
+		{uri: "/?limit=ten", dest: &query{}, key: "limit", field: "Limit", value: "ten"},
+		{uri: "/?ids=1,x,3", dest: &query{}, key: "ids", field: "IDs", value: "x"},
+		{uri: "/?user[age]=300", dest: &nested{}, key: "user[age]", field: "User.Age", value: "300"},
+		{uri: "/?items[0][count]=1&items[1][count]=a", dest: &query{}, key: "items[1][count]", field: "Items[1].Count", value: "a"},
+		{uri: "/?filter[open]=yes", dest: &query{}, key: "filter[open]", field: "Filter[open]", value: "yes"},
+		{uri: "/?keys[one]=1", dest: &query{}, key: "keys[one]", field: "Keys[one]", value: "one"},
+		{uri: "/?addr=localhost", dest: &query{}, key: "addr", field: "Addr", value: "localhost"},
+	}
+	for _, tt := range tests {
+		err := NewDecoder().Decode(tt.uri, tt.dest)
+		var fieldErr *FieldError
+		if test.True(errors.As(err, &fieldErr), tt.uri) {
+			test.Equal(tt.key, fieldErr.Key, tt.uri)
+			test.Equal(tt.field, fieldErr.Field, tt.uri)
+			test.Equal(tt.value, fieldErr.Value, tt.uri)
+		}
+	}
+
+	err := NewDecoder().Decode("/?limit=ten", &query{})
+	test.True(errors.Is(err, strconv.ErrSyntax))
+	test.EqualError(err, `qs: parameter "limit" (field Limit): invalid value "ten": strconv.ParseInt: parsing "ten": invalid syntax`)
+
+	err = NewDecoder().Decode("/?ch=1", &struct {
+		Ch chan int `query:"ch"`
+	}{})
+	test.True(errors.Is(err, ErrUnknownType))
+}
+
+func TestEncodeFieldError(t *testing.T) {
+	test := assert.New(t)
+	encoder := NewEncoder()
+
+	type inner struct {
+		Err errText `query:"err"`
+	}
+	tests := []struct {
+		src   any
+		key   string
+		field string
+	}{
+		{src: struct {
+			Err errText `query:"err"`
+		}{}, key: "err", field: "Err"},
+		{src: struct {
+			Inner inner `query:"inner"`
+		}{}, key: "inner[err]", field: "Inner.Err"},
+		{src: struct {
+			Errs []errText `query:"errs,bracket"`
+		}{Errs: []errText{{}}}, key: "errs[]", field: "Errs[0]"},
+		{src: struct {
+			Errs []errText `query:"errs,index"`
+		}{Errs: []errText{{}}}, key: "errs[0]", field: "Errs[0]"},
+		{src: struct {
+			Errs map[string]errText `query:"errs"`
+		}{Errs: map[string]errText{"a": {}}}, key: "errs[a]", field: "Errs[a]"},
+	}
+	for _, tt := range tests {
+		_, err := encoder.Values(tt.src)
+		var fieldErr *FieldError
+		if test.True(errors.As(err, &fieldErr)) {
+			test.Equal(tt.key, fieldErr.Key)
+			test.Equal(tt.field, fieldErr.Field)
+			test.Empty(fieldErr.Value)
+		}
+	}
+
+	_, err := encoder.Values(struct {
+		Err errText `query:"err"`
+	}{})
+	test.True(errors.Unwrap(err) != nil)
+	test.EqualError(err, `qs: parameter "err" (field Err): failed to marshal text`)
+}
+
+func TestDecodeCollectErrors(t *testing.T) {
+	test := assert.New(t)
+
+	type query struct {
+		Index  int            `path:"index"`
+		Limit  int            `query:"limit"`
+		Page   int            `query:"page"`
+		Sort   string         `query:"sort"`
+		Filter map[string]int `query:"filter"`
+		Addr   netip.Addr     `query:"addr"`
+	}
+
+	decoder := NewDecoder(
+		WithPathValues(map[string]string{"index": "default"}),
+		WithCollectErrors(),
+	)
+	var dest query
+	err := decoder.Decode("/?addr=local&filter[b]=2&filter[c]=x&filter[a]=y&sort=asc&page=2&limit=ten", &dest)
+
+	var errs FieldErrors
+	if test.True(errors.As(err, &errs)) {
+		keys := make([]string, 0, len(errs))
+		for _, err := range errs {
+			keys = append(keys, err.Key)
+		}
+		test.Equal([]string{"index", "limit", "filter[a]", "filter[c]", "addr"}, keys)
+	}
+	test.True(errors.Is(err, strconv.ErrSyntax))
+	test.Equal(2, dest.Page)
+	test.Equal("asc", dest.Sort)
+	test.Equal(map[string]int{"b": 2}, dest.Filter)
+
+	var fieldErr *FieldError
+	test.True(errors.As(err, &fieldErr))
+	test.Equal("index", fieldErr.Key)
+
+	// without the option binding stops at the first failure
+	err = NewDecoder().Decode("/?page=x&limit=ten", &dest)
+	test.True(errors.As(err, &fieldErr))
+	test.False(errors.As(err, &errs))
+	test.Equal("limit", fieldErr.Key)
+
+	test.NoError(NewDecoder(WithCollectErrors()).Decode("/?page=3", &query{}))
+}
@@ -0,0 +1,88 @@
+package qs
+
+import "reflect"
+
+// Field holds the value of a parameter and whether it was given, telling a
+// query with an explicitly empty `q=` from one without q. The Decoder sets the
+// fields whose parameter is present, a value bound from a `default` tag leaves
+// the field unset. The Encoder omits unset fields and encodes the others like
+// a field of type T, even when the value is empty.
+//
+// T is a type bound from a single parameter: a scalar, a pointer, a slice or
+// an array, a time.Time, a custom type or an unmarshaler.
+type Field[T any] struct {
+	value T
+	set   bool
+}
+
+// NewField returns a set Field holding value.
+func NewField[T any](value T) Field[T] {
+	return Field[T]{value: value, set: true}
+}
+
+// Value returns the value of the field, the zero value of T when it is unset.
+func (f Field[T]) Value() T {
+	return f.value
+}
+
+// IsSet reports whether the field was given.
+func (f Field[T]) IsSet() bool {
+	return f.set
+}
+
+// Set sets the field to value.
+func (f *Field[T]) Set(value T) {
+	f.value = value
+	f.set = true
+}
+
+// Unset clears the field.
+func (f *Field[T]) Unset() {
+	var zero T
+	f.value = zero
+	f.set = false
+}
+
+func (f *Field[T]) elemType() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+func (f *Field[T]) elem() reflect.Value {
+	return reflect.ValueOf(&f.value).Elem()
+}
+
+func (f *Field[T]) markSet() {
+	f.set = true
+}
+
+// presence is implemented by pointers to a Field, it gives the binder and the
+// encoder access to the value of the Field whatever T is.
+type presence interface {
+	IsSet() bool
+	elemType() reflect.Type
+	elem() reflect.Value
+	markSet()
+}
+
+var presenceType = reflect.TypeOf(new(presence)).Elem()
+
+// isPresenceType reports whether typ is a Field.
+func isPresenceType(typ reflect.Type) bool {
+	return typ.Kind() == reflect.Struct && reflect.PointerTo(typ).Implements(presenceType)
+}
+
+// presenceElemType returns T for the type of a Field[T].
+func presenceElemType(typ reflect.Type) reflect.Type {
+	return reflect.Zero(reflect.PointerTo(typ)).Interface().(presence).elemType()
+}
+
+// presenceOf returns the Field held by v. A Field that isn't addressable is
+// copied, its value can be read but setting it has no effect on v.
+func presenceOf(v reflect.Value) presence {
+	if v.CanAddr() {
+		return v.Addr().Interface().(presence)
+	}
+	ptr := reflect.New(v.Type())
+	ptr.Elem().Set(v)
+	return ptr.Interface().(presence)
+}
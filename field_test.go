@@ -0,0 +1,150 @@
+package qs
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeField(t *testing.T) {
+	test := assert.New(t)
+
+	type query struct {
+		Q      Field[string]    `query:"q"`
+		Limit  Field[int]       `query:"limit" default:"20"`
+		Tags   Field[[]string]  `query:"tags,comma"`
+		Page   *Field[int]      `query:"page"`
+		Since  Field[time.Time] `query:"since,second"`
+		Sort   Field[sortOrder] `query:"sort"`
+		Offset Field[*int]      `query:"offset"`
+	}
+
+	var dest query
+	test.NoError(NewDecoder().Decode("/?q=&tags=a,b&since=600&sort=desc", &dest))
+	test.True(dest.Q.IsSet())
+	test.Equal("", dest.Q.Value())
+	// a default value leaves the field unset
+	test.False(dest.Limit.IsSet())
+	test.Equal(20, dest.Limit.Value())
+	test.True(dest.Tags.IsSet())
+	test.Equal([]string{"a", "b"}, dest.Tags.Value())
+	test.Nil(dest.Page)
+	test.Equal(int64(600), dest.Since.Value().Unix())
+	test.Equal(sortOrder("desc"), dest.Sort.Value())
+	test.False(dest.Offset.IsSet())
+	test.Nil(dest.Offset.Value())
+
+	dest = query{}
+	test.NoError(NewDecoder().Decode("/?limit=5&page=2&offset=0", &dest))
+	test.False(dest.Q.IsSet())
+	test.Equal(NewField(5), dest.Limit)
+	if test.NotNil(dest.Page) {
+		test.Equal(NewField(2), *dest.Page)
+	}
+	if test.True(dest.Offset.IsSet()) && test.NotNil(dest.Offset.Value()) {
+		test.Equal(0, *dest.Offset.Value())
+	}
+
+	err := NewDecoder().Decode("/?limit=x", &query{})
+	test.EqualError(err, `qs: parameter "limit" (field Limit): invalid value "x": strconv.ParseInt: parsing "x": invalid syntax`)
+
+	test.Error(NewDecoder().Decode("/", &struct {
+		User Field[struct {
+			Name string `query:"name"`
+		}] `query:"user"`
+	}{}))
+	test.Error(NewDecoder().Decode("/", &struct {
+		Filter Field[map[string]string] `query:"filter"`
+	}{}))
+}
+
+func TestValidateField(t *testing.T) {
+	test := assert.New(t)
+
+	type query struct {
+		Q     Field[string] `query:"q" validate:"required"`
+		Limit Field[int]    `query:"limit" validate:"min=1,max=100"`
+		Page  Field[int]    `query:"page" validate:"excluded_with=Limit"`
+	}
+
+	decoder := NewDecoder()
+	// a present parameter is given, even when it is empty
+	test.NoError(decoder.Decode("/?q=", &query{}))
+	test.NoError(decoder.Decode("/?q=x&limit=10", &query{}))
+	test.NoError(decoder.Decode("/?q=x&page=0", &query{}))
+	test.EqualError(decoder.Decode("/", &query{}), `qs: parameter "q" (field Q): is required`)
+	test.EqualError(decoder.Decode("/?q=&limit=0", &query{}), `qs: parameter "limit" (field Limit): invalid value "0": must be at least 1`)
+	test.EqualError(decoder.Decode("/?q=&limit=101", &query{}), `qs: parameter "limit" (field Limit): invalid value "101": must be at most 100`)
+	test.EqualError(decoder.Decode("/?q=&limit=1&page=", &query{}), `qs: parameter "page" (field Page): invalid value "0": is not allowed with limit`)
+}
+
+func TestEncodeField(t *testing.T) {
+	test := assert.New(t)
+
+	type filter struct {
+		Field Field[string] `query:"field"`
+	}
+	type query struct {
+		Q      Field[string]    `query:"q"`
+		Limit  Field[int]       `query:"limit"`
+		Tags   Field[[]string]  `query:"tags,comma"`
+		IDs    Field[[]int]     `query:"ids"`
+		Page   *Field[int]      `query:"page"`
+		Since  Field[time.Time] `query:"since,second"`
+		Size   Field[int]       `query:"size,omitdefault" default:"10"`
+		Filter filter           `query:"filter"`
+	}
+
+	values, err := NewEncoder().Values(query{})
+	test.NoError(err)
+	test.Equal(url.Values{}, values)
+
+	page := NewField(2)
+	src := query{
+		Q:      NewField(""),
+		Limit:  NewField(0),
+		Tags:   NewField([]string{"a", "b"}),
+		IDs:    NewField([]int{1, 2}),
+		Page:   &page,
+		Since:  NewField(time.Unix(600, 0)),
+		Size:   NewField(10),
+		Filter: filter{Field: NewField("x")},
+	}
+	values, err = NewEncoder().Values(&src)
+	test.NoError(err)
+	test.Equal(url.Values{
+		"q":             []string{""},
+		"limit":         []string{"0"},
+		"tags":          []string{"a,b"},
+		"ids":           []string{"1", "2"},
+		"page":          []string{"2"},
+		"since":         []string{"600"},
+		"filter[field]": []string{"x"},
+	}, values)
+
+	var dest query
+	test.NoError(NewDecoder().Decode("/?"+values.Encode(), &dest))
+	test.Equal(src.Q, dest.Q)
+	test.Equal(src.Limit, dest.Limit)
+	test.Equal(src.Tags, dest.Tags)
+	test.Equal(src.Filter, dest.Filter)
+	test.False(dest.Size.IsSet())
+	test.Equal(10, dest.Size.Value())
+}
+
+func TestFieldMethods(t *testing.T) {
+	test := assert.New(t)
+
+	var f Field[int]
+	test.False(f.IsSet())
+	f.Set(0)
+	test.True(f.IsSet())
+	test.Equal(0, f.Value())
+	f.Set(3)
+	test.Equal(NewField(3), f)
+	f.Unset()
+	test.False(f.IsSet())
+	test.Equal(0, f.Value())
+}
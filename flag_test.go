@@ -0,0 +1,99 @@
+package qs
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodeFlag(t *testing.T) {
+	test := assert.New(t)
+
+	type query struct {
+		Debug   bool        `query:"debug,flag"`
+		Verbose *bool       `query:"verbose,flag"`
+		Pretty  Field[bool] `query:"pretty,flag"`
+		Active  bool        `query:"active"`
+		Options struct {
+			Explain bool `query:"explain,flag"`
+		} `query:"options"`
+	}
+
+	yes := true
+	src := query{Debug: true, Verbose: &yes, Pretty: NewField(true)}
+	src.Options.Explain = true
+	values, err := NewEncoder().Values(src)
+	test.NoError(err)
+	test.Equal(url.Values{
+		"debug":            nil,
+		"verbose":          nil,
+		"pretty":           nil,
+		"active":           {"false"},
+		"options[explain]": nil,
+	}, values)
+	test.Equal("active=false&debug&options%5Bexplain%5D&pretty&verbose", EncodeValues(values))
+
+	no := false
+	for _, src := range []query{{}, {Verbose: &no, Pretty: NewField(false)}} {
+		values, err = NewEncoder(WithNullPolicy(NullBareKey)).Values(src)
+		test.NoError(err)
+		test.Equal(url.Values{"active": {"false"}}, values)
+	}
+}
+
+func TestDecodeFlag(t *testing.T) {
+	test := assert.New(t)
+
+	type query struct {
+		Debug   bool        `query:"debug,flag"`
+		Verbose *bool       `query:"verbose,flag"`
+		Pretty  Field[bool] `query:"pretty,flag"`
+		Active  bool        `query:"active"`
+	}
+
+	tests := []struct {
+		uri    string
+		option DecoderOption
+		want   bool
+	}{
+		{uri: "/?debug&verbose&pretty&active", want: true},
+		{uri: "/?debug=&verbose=&pretty=&active=", want: true},
+		{uri: "/?debug&verbose&pretty&active", option: WithDecoderNullPolicy(NullBareKey), want: true},
+		{uri: "/?debug=true&verbose=1&pretty=true&active=true", want: true},
+		{uri: "/?debug=false&verbose=0&pretty=false&active=false", want: false},
+	}
+	for _, tt := range tests {
+		var options []DecoderOption
+		if tt.option != nil {
+			options = append(options, tt.option)
+		}
+		var dest query
+		test.NoError(NewDecoder(options...).Decode(tt.uri, &dest), tt.uri)
+		test.Equal(tt.want, dest.Debug, tt.uri)
+		if test.NotNil(dest.Verbose, tt.uri) {
+			test.Equal(tt.want, *dest.Verbose, tt.uri)
+		}
+		test.True(dest.Pretty.IsSet(), tt.uri)
+		test.Equal(tt.want, dest.Pretty.Value(), tt.uri)
+	}
+
+	// bools without the flag option keep treating an empty value as false
+	var dest query
+	test.NoError(NewDecoder().Decode("/?active", &dest))
+	test.False(dest.Active)
+	test.False(dest.Debug)
+	test.Nil(dest.Verbose)
+	test.False(dest.Pretty.IsSet())
+
+	test.Error(NewDecoder().Decode("/?debug=yes", &query{}))
+
+	// round trip
+	src := query{Debug: true, Pretty: NewField(false)}
+	values, err := NewEncoder().Values(src)
+	test.NoError(err)
+	dest = query{}
+	test.NoError(NewDecoder().Decode("/?"+EncodeValues(values), &dest))
+	test.Equal(src.Debug, dest.Debug)
+	test.Equal(src.Pretty.Value(), dest.Pretty.Value())
+}
@@ -1,6 +1,6 @@
 module github.com/ohzqq/qs
 
-go 1.19
+go 1.23
 
 require (
 	github.com/pkg/errors v0.9.1
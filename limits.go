@@ -0,0 +1,101 @@
+package qs
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// limits caps the input of a binding, a zero limit isn't enforced.
+type limits struct {
+	maxParams      int
+	maxValueLength int
+	maxDepth       int
+	maxListLength  int
+	maxMapEntries  int
+}
+
+// countParams counts the parameters of a raw query string without parsing
+// it, the way url.ParseQuery splits them.
+func countParams(query string) int {
+	count := 0
+	for query != "" {
+		var param string
+		param, query, _ = strings.Cut(query, "&")
+		if param != "" {
+			count++
+		}
+	}
+	return count
+}
+
+// checkLimits enforces the limits on the number of parameters, the length of
+// their values and their nesting depth before anything is bound. Parameters
+// are checked in the order of their names.
+func (params *paramSet) checkLimits() error {
+	l := params.limits
+	if l.maxParams > 0 {
+		count := 0
+		for _, values := range params.data {
+			count += len(values)
+		}
+		if count > l.maxParams {
+			return &LimitError{Limit: LimitParams, Max: l.maxParams}
+		}
+	}
+	if l.maxValueLength == 0 && l.maxDepth == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(params.data))
+	for k := range params.data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		var err error
+		if l.maxDepth > 0 && strings.Count(k, "[") > l.maxDepth {
+			err = &FieldError{Key: k, Err: &LimitError{Limit: LimitDepth, Max: l.maxDepth}}
+		} else if l.maxValueLength > 0 {
+			for _, v := range params.data[k] {
+				if len(v) > l.maxValueLength {
+					err = &FieldError{Key: k, Err: &LimitError{Limit: LimitValueLength, Max: l.maxValueLength}}
+					break
+				}
+			}
+		}
+		if err := params.fail(err); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkList enforces the list length limit on the values of the list name
+// and, when indexed, on the indexes of its `name[i]` parameters.
+func (params *paramSet) checkList(name string, indexed bool, values []string) error {
+	limit := params.limits.maxListLength
+	if limit == 0 {
+		return nil
+	}
+	if len(values) > limit {
+		return &LimitError{Limit: LimitListLength, Max: limit}
+	}
+	if !indexed {
+		return nil
+	}
+	prefix := name + "["
+	for _, k := range params.scan(prefix) {
+		rest := k[len(prefix):]
+		end := strings.IndexByte(rest, ']')
+		if end < 1 {
+			continue
+		}
+		index, err := strconv.Atoi(rest[:end])
+		if err != nil || index < limit {
+			continue
+		}
+		return &FieldError{Key: k, Err: &LimitError{Limit: LimitListLength, Max: limit}}
+	}
+	return nil
+}
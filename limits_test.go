@@ -0,0 +1,133 @@
+package qs
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeLimits(t *testing.T) {
+	test := assert.New(t)
+
+	type item struct {
+		Name string `query:"name"`
+	}
+	type query struct {
+		Q      string            `query:"q"`
+		Tags   []string          `query:"tags,index"`
+		IDs    []int             `query:"ids,comma"`
+		Items  []item            `query:"items"`
+		Filter map[string]string `query:"filter"`
+		User   struct {
+			Address struct {
+				City string `query:"city"`
+			} `query:"address"`
+		} `query:"user"`
+	}
+
+	tests := []struct {
+		name    string
+		option  DecoderOption
+		uri     string
+		limit   string
+		key     string
+		allowed string
+	}{
+		{
+			name: "params", option: WithMaxParams(3),
+			uri: "/?q=a&q=b&tags[0]=c&ids=1", limit: LimitParams,
+			allowed: "/?q=a&&tags[0]=c&ids=1",
+		},
+		{
+			name: "value length", option: WithMaxValueLength(4),
+			uri: "/?q=abcde", limit: LimitValueLength, key: "q",
+			allowed: "/?q=abcd",
+		},
+		{
+			name: "depth", option: WithMaxDepth(1),
+			uri: "/?user[address][city]=Paris", limit: LimitDepth, key: "user[address][city]",
+			allowed: "/?tags[0]=a&filter[k]=v",
+		},
+		{
+			name: "list index", option: WithMaxListLength(3),
+			uri: "/?tags[999999999]=x", limit: LimitListLength, key: "tags[999999999]",
+			allowed: "/?tags[2]=x",
+		},
+		{
+			name: "list length", option: WithMaxListLength(3),
+			uri: "/?ids=1,2,3,4", limit: LimitListLength, key: "ids",
+			allowed: "/?ids=1,2,3",
+		},
+		{
+			name: "comma list length", option: WithMaxListLength(3),
+			uri: "/?ids=1,2&ids=3," + strings.Repeat("4,", 100000), limit: LimitListLength, key: "ids",
+			allowed: "/?ids=1,2&ids=3",
+		},
+		{
+			name: "struct list index", option: WithMaxListLength(3),
+			uri: "/?items[3][name]=x", limit: LimitListLength, key: "items[3][name]",
+			allowed: "/?items[2][name]=x",
+		},
+		{
+			name: "map entries", option: WithMaxMapEntries(2),
+			uri: "/?filter[a]=1&filter[b]=2&filter[c]=3", limit: LimitMapEntries, key: "filter",
+			allowed: "/?filter[a]=1&filter[b]=2",
+		},
+	}
+	for _, tt := range tests {
+		decoder := NewDecoder(tt.option)
+		test.NoError(decoder.Decode(tt.allowed, &query{}), tt.name)
+
+		var dest query
+		err := decoder.Decode(tt.uri, &dest)
+		test.True(errors.Is(err, ErrLimitExceeded), tt.name)
+		var limitErr *LimitError
+		if test.True(errors.As(err, &limitErr), tt.name) {
+			test.Equal(tt.limit, limitErr.Limit, tt.name)
+		}
+		var fieldErr *FieldError
+		if tt.key == "" {
+			test.False(errors.As(err, &fieldErr), tt.name)
+		} else if test.True(errors.As(err, &fieldErr), tt.name) {
+			test.Equal(tt.key, fieldErr.Key, tt.name)
+		}
+		test.Nil(dest.Tags, tt.name)
+		test.Nil(dest.Items, tt.name)
+	}
+
+	// limits are enforced by the binder too
+	binder := NewDecoder(WithMaxParams(1)).binder
+	err := binder.BindQueryParams(map[string][]string{"q": {"a", "b"}}, &query{})
+	test.True(errors.Is(err, ErrLimitExceeded))
+
+	err = NewDecoder(WithMaxValueLength(2), WithCollectErrors()).Decode("/?q=abc&ids="+strings.Repeat("1", 3), &query{})
+	var errs FieldErrors
+	if test.True(errors.As(err, &errs)) && test.Len(errs, 2) {
+		test.Equal("ids", errs[0].Key)
+		test.Equal("q", errs[1].Key)
+	}
+}
+
+func TestLookupCommaListLimit(t *testing.T) {
+	test := assert.New(t)
+
+	params := newParamSet(map[string][]string{"ids": {strings.Repeat("1,", 100000)}}, true)
+	params.limits.maxListLength = 3
+	values, exists := params.lookupList("ids", "", arrayFormatComma)
+	test.True(exists)
+	test.Len(values, 4)
+
+	params.data["ids"] = []string{"1,2", "", "3"}
+	values, _ = params.lookupList("ids", "", arrayFormatComma)
+	test.Equal([]string{"1", "2", "3"}, values)
+}
+
+func TestCountParams(t *testing.T) {
+	test := assert.New(t)
+	test.Equal(0, countParams(""))
+	test.Equal(1, countParams("a=1"))
+	test.Equal(2, countParams("a=1&&b"))
+	test.Equal(3, countParams("a=1&a=2&b=3&"))
+}
@@ -0,0 +1,110 @@
+package qs
+
+import (
+	"net/url"
+	"reflect"
+	"strings"
+)
+
+// NullPolicy selects how a nil pointer is encoded, and which parameters the
+// Decoder binds to a pointer field as nil.
+type NullPolicy uint8
+
+const (
+	// NullEmpty encodes nil as an empty value, `name=`, it is the default. An
+	// empty value is bound as a pointer to the zero value.
+	NullEmpty NullPolicy = iota
+	// NullOmit omits nil pointers, an absent parameter leaves the pointer nil
+	NullOmit
+	// NullBareKey encodes nil as a key without value, `name`, and binds a
+	// bare key as nil
+	NullBareKey
+	// NullSentinel encodes nil as the sentinel value, `name=null` by default,
+	// and binds the sentinel as nil
+	NullSentinel
+)
+
+// defaultNullSentinel is the sentinel of NullSentinel unless another one is
+// set with WithNullSentinel or WithDecoderNullSentinel
+const defaultNullSentinel = "null"
+
+// nullSentinelOf returns sentinel, or the default sentinel when it is empty.
+func nullSentinelOf(sentinel string) string {
+	if sentinel == "" {
+		return defaultNullSentinel
+	}
+	return sentinel
+}
+
+// isNullParam reports whether the parameter named key is given and null, it
+// sets a pointer to a nested struct or struct list to nil.
+func (b *DefaultBinder) isNullParam(params *paramSet, key string) bool {
+	values, exists := params.lookup(key, "")
+	return exists && b.isNull(values)
+}
+
+// isNull reports whether the values of a parameter bound to a pointer field
+// stand for nil under the binder's null policy. A bare key has no values.
+func (b *DefaultBinder) isNull(values []string) bool {
+	switch b.nullPolicy {
+	case NullBareKey:
+		return len(values) == 0
+	case NullSentinel:
+		if len(values) == 0 {
+			return false
+		}
+		sentinel := nullSentinelOf(b.nullSentinel)
+		for _, v := range values {
+			if v != sentinel {
+				return false
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+// bareParams removes the values of the parameters of query only given as
+// bare keys in rawQuery, like `debug` in `debug&q=x`, so they are bound as
+// parameters without values.
+func bareParams(rawQuery string, query url.Values) {
+	bare := make(map[string]int)
+	for rawQuery != "" {
+		var param string
+		param, rawQuery, _ = strings.Cut(rawQuery, "&")
+		if param == "" || strings.Contains(param, "=") {
+			continue
+		}
+		key, err := url.QueryUnescape(param)
+		if err != nil {
+			continue
+		}
+		bare[key]++
+	}
+	for key, count := range bare {
+		if values, ok := query[key]; ok && len(values) == count {
+			query[key] = nil
+		}
+	}
+}
+
+// nullable wraps the cached field of a struct field of type typ to encode a
+// nil pointer following the encoder's null policy. Fields omitted when empty,
+// flags and maps are left as they are.
+func (e *Encoder) nullable(field cachedField, typ reflect.Type, tagName []byte, tagOptions [][]byte) cachedField {
+	switch field.(type) {
+	case nil, *mapField:
+		return field
+	}
+	if e.nullPolicy == NullEmpty || typ.Kind() != reflect.Ptr ||
+		hasTagOption(tagOptions, tagOmitEmpty) || hasTagOption(tagOptions, tagFlag) {
+		return field
+	}
+	return &nullField{
+		cachedField: field,
+		name:        string(tagName),
+		policy:      e.nullPolicy,
+		sentinel:    nullSentinelOf(e.nullSentinel),
+	}
+}
@@ -0,0 +1,202 @@
+package qs
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodeNullPolicy(t *testing.T) {
+	test := assert.New(t)
+
+	type user struct {
+		Name *string `query:"name"`
+	}
+	type query struct {
+		Limit *int        `query:"limit"`
+		Q     *string     `query:"q,omitempty"`
+		Since *time.Time  `query:"since"`
+		Sort  *sortOrder  `query:"sort"`
+		Tags  *[]string   `query:"tags"`
+		Page  Field[*int] `query:"page"`
+		Size  int         `query:"size"`
+		User  user        `query:"user"`
+		Owner *user       `query:"owner"`
+	}
+	src := query{Page: NewField[*int](nil)}
+
+	tests := []struct {
+		option  EncoderOption
+		values  url.Values
+		encoded string
+	}{
+		{
+			option: WithNullPolicy(NullEmpty),
+			values: url.Values{
+				"limit": {""}, "since": {""}, "sort": {""}, "page": {""},
+				"size": {"0"}, "user[name]": {""}, "owner": {""},
+			},
+			encoded: "limit=&owner=&page=&since=&size=0&sort=&user%5Bname%5D=",
+		},
+		{
+			option:  WithNullPolicy(NullOmit),
+			values:  url.Values{"size": {"0"}},
+			encoded: "size=0",
+		},
+		{
+			option: WithNullPolicy(NullBareKey),
+			values: url.Values{
+				"limit": nil, "since": nil, "sort": nil, "tags": nil, "page": nil,
+				"size": {"0"}, "user[name]": nil, "owner": nil,
+			},
+			encoded: "limit&owner&page&since&size=0&sort&tags&user%5Bname%5D",
+		},
+		{
+			option: WithNullPolicy(NullSentinel),
+			values: url.Values{
+				"limit": {"null"}, "since": {"null"}, "sort": {"null"}, "tags": {"null"}, "page": {"null"},
+				"size": {"0"}, "user[name]": {"null"}, "owner": {"null"},
+			},
+			encoded: "limit=null&owner=null&page=null&since=null&size=0&sort=null&tags=null&user%5Bname%5D=null",
+		},
+		{
+			option: WithNullSentinel("~"),
+			values: url.Values{
+				"limit": {"~"}, "since": {"~"}, "sort": {"~"}, "tags": {"~"}, "page": {"~"},
+				"size": {"0"}, "user[name]": {"~"}, "owner": {"~"},
+			},
+			encoded: "limit=~&owner=~&page=~&since=~&size=0&sort=~&tags=~&user%5Bname%5D=~",
+		},
+	}
+	for _, tt := range tests {
+		values, err := NewEncoder(tt.option).Values(src)
+		test.NoError(err)
+		test.Equal(tt.values, values)
+		test.Equal(tt.encoded, EncodeValues(values))
+	}
+
+	// pointers that aren't nil are encoded as usual
+	limit := 0
+	values, err := NewEncoder(WithNullPolicy(NullBareKey)).Values(query{Limit: &limit, Page: NewField(&limit)})
+	test.NoError(err)
+	test.Equal([]string{"0"}, values["limit"])
+	test.Equal([]string{"0"}, values["page"])
+
+	// nested struct caching doesn't rename the field
+	type item struct {
+		Name string `query:"name"`
+	}
+	type items struct {
+		Items *[]item `query:"items,index"`
+		Tags  *[]item `query:"tags"`
+	}
+	values, err = NewEncoder(WithNullPolicy(NullSentinel)).Values(items{})
+	test.NoError(err)
+	test.Equal(url.Values{"items": {"null"}, "tags": {"null"}}, values)
+
+	// a nil nested struct decodes back without unknown parameters
+	for _, policy := range []NullPolicy{NullOmit, NullBareKey, NullSentinel} {
+		values, err := NewEncoder(WithNullPolicy(policy)).Values(query{Size: 1})
+		test.NoError(err)
+		var dest query
+		decoder := NewDecoder(WithDecoderNullPolicy(policy), WithDisallowUnknownParams())
+		test.NoError(decoder.Decode("/?"+EncodeValues(values), &dest))
+		test.Nil(dest.Owner)
+		test.Equal(1, dest.Size)
+
+		values, err = NewEncoder(WithNullPolicy(policy)).Values(items{})
+		test.NoError(err)
+		var list items
+		if policy != NullOmit {
+			list = items{Items: &[]item{{Name: "a"}}, Tags: &[]item{{Name: "b"}}}
+		}
+		test.NoError(decoder.Decode("/?"+EncodeValues(values), &list))
+		test.Equal(items{}, list)
+	}
+
+	// a null nested struct replaces the one of the destination
+	for _, policy := range []NullPolicy{NullBareKey, NullSentinel} {
+		values, err := NewEncoder(WithNullPolicy(policy)).Values(query{})
+		test.NoError(err)
+		name := "a"
+		dest := query{Owner: &user{Name: &name}}
+		test.NoError(NewDecoder(WithDecoderNullPolicy(policy)).Decode("/?"+EncodeValues(values), &dest))
+		test.Nil(dest.Owner)
+	}
+}
+
+func TestDecodeNullPolicy(t *testing.T) {
+	test := assert.New(t)
+
+	type query struct {
+		Limit  *int        `query:"limit"`
+		Since  *time.Time  `query:"since"`
+		Sort   *sortOrder  `query:"sort"`
+		Tags   *[]string   `query:"tags,comma"`
+		Page   Field[*int] `query:"page"`
+		Q      string      `query:"q"`
+		Offset *int        `query:"offset"`
+	}
+
+	var dest query
+	test.NoError(NewDecoder().Decode("/?limit&offset=", &dest))
+	if test.NotNil(dest.Limit) && test.NotNil(dest.Offset) {
+		test.Equal(0, *dest.Limit)
+		test.Equal(0, *dest.Offset)
+	}
+
+	tests := []struct {
+		option DecoderOption
+		uri    string
+	}{
+		{option: WithDecoderNullPolicy(NullBareKey), uri: "/?limit&since&sort&tags&page&q&offset=1"},
+		{option: WithDecoderNullPolicy(NullSentinel), uri: "/?limit=null&since=null&sort=null&tags=null&page=null&q=null&offset=1"},
+		{option: WithDecoderNullSentinel("~"), uri: "/?limit=~&since=~&sort=~&tags=~&page=~&q=~&offset=1"},
+	}
+	for _, tt := range tests {
+		one := 1
+		dest := query{Limit: &one, Tags: &[]string{"a"}}
+		test.NoError(NewDecoder(tt.option).Decode(tt.uri, &dest), tt.uri)
+		test.Nil(dest.Limit, tt.uri)
+		test.Nil(dest.Since, tt.uri)
+		test.Nil(dest.Sort, tt.uri)
+		test.Nil(dest.Tags, tt.uri)
+		test.True(dest.Page.IsSet(), tt.uri)
+		test.Nil(dest.Page.Value(), tt.uri)
+		if test.NotNil(dest.Offset, tt.uri) {
+			test.Equal(1, *dest.Offset, tt.uri)
+		}
+	}
+
+	// an empty value isn't a bare key, a key given with a value isn't null
+	dest = query{}
+	test.NoError(NewDecoder(WithDecoderNullPolicy(NullBareKey)).Decode("/?limit=&offset&offset=2&q", &dest))
+	if test.NotNil(dest.Limit) && test.NotNil(dest.Offset) {
+		test.Equal(0, *dest.Limit)
+		test.Equal(0, *dest.Offset)
+	}
+	dest = query{}
+	test.NoError(NewDecoder(WithDecoderNullPolicy(NullSentinel)).Decode("/?q=null", &dest))
+	test.Equal("null", dest.Q)
+
+	// round trip
+	encoder := NewEncoder(WithNullPolicy(NullBareKey))
+	decoder := NewDecoder(WithDecoderNullPolicy(NullBareKey))
+	values, err := encoder.Values(query{Page: NewField[*int](nil)})
+	test.NoError(err)
+	dest = query{}
+	test.NoError(decoder.Decode("/?"+EncodeValues(values), &dest))
+	test.Nil(dest.Limit)
+	test.True(dest.Page.IsSet())
+	test.Nil(dest.Page.Value())
+}
+
+func TestEncodeValues(t *testing.T) {
+	test := assert.New(t)
+	test.Equal("", EncodeValues(nil))
+	test.Equal("a=1&a=2&b&c+d=e%26f", EncodeValues(url.Values{"b": nil, "a": {"1", "2"}, "c d": {"e&f"}}))
+	values := url.Values{"q": {"x y"}, "tags": {"a", "b"}}
+	test.Equal(values.Encode(), EncodeValues(values))
+}
@@ -0,0 +1,108 @@
+package qshttp
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/ohzqq/qs"
+)
+
+// ContentType is the media type of the responses written by WriteProblem.
+const ContentType = "application/problem+json"
+
+// Problem is an RFC 7807 problem details object.
+type Problem struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail,omitempty"`
+	// InvalidParams lists the parameters that failed to bind or to validate
+	InvalidParams []InvalidParam `json:"invalid-params,omitempty"`
+}
+
+// InvalidParam describes a parameter of a Problem.
+type InvalidParam struct {
+	// Name is the parameter name, e.g. `limit` or `user[from]`
+	Name string `json:"name"`
+	// Value is the raw parameter value, when there is one
+	Value string `json:"value,omitempty"`
+	// Reason explains why the parameter is invalid
+	Reason string `json:"reason"`
+}
+
+// NewProblem returns the Problem describing err, an error returned by a
+// qs.Decoder. Parameters that fail to bind or to validate and exceeded limits
+// are a 400 Bad Request, a path that doesn't match the route of a Decoder
+// created with qs.WithRoute is a 404 Not Found, other errors like invalid
+// struct tags or path values missing from the route pattern are a 500 Internal
+// Server Error whose detail isn't disclosed.
+func NewProblem(err error) *Problem {
+	var fieldErrs qs.FieldErrors
+	var fieldErr *qs.FieldError
+	var limitErr *qs.LimitError
+	switch {
+	case errors.Is(err, qs.ErrMissingPathValue):
+		return newProblem(http.StatusInternalServerError)
+	case errors.Is(err, qs.ErrPathMismatch):
+		return newProblem(http.StatusNotFound)
+	case errors.As(err, &fieldErrs):
+	case errors.As(err, &fieldErr):
+		fieldErrs = qs.FieldErrors{fieldErr}
+	case errors.As(err, &limitErr):
+		problem := newProblem(http.StatusBadRequest)
+		problem.Detail = "the request has too many parameters, the limit is " + strconv.Itoa(limitErr.Max)
+		return problem
+	default:
+		return newProblem(http.StatusInternalServerError)
+	}
+
+	problem := newProblem(http.StatusBadRequest)
+	if len(fieldErrs) == 1 {
+		problem.Detail = "the request has an invalid parameter"
+	} else {
+		problem.Detail = "the request has " + strconv.Itoa(len(fieldErrs)) + " invalid parameters"
+	}
+	problem.InvalidParams = make([]InvalidParam, len(fieldErrs))
+	for i, fieldErr := range fieldErrs {
+		problem.InvalidParams[i] = InvalidParam{
+			Name:   fieldErr.Key,
+			Value:  fieldErr.Value,
+			Reason: reason(fieldErr.Err),
+		}
+	}
+	return problem
+}
+
+func newProblem(status int) *Problem {
+	return &Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+	}
+}
+
+// reason returns the message of the cause of a FieldError, a failure to
+// parse a number is reported without the name of the Go function.
+func reason(err error) string {
+	var numErr *strconv.NumError
+	switch {
+	case err == nil:
+		return "invalid value"
+	case errors.As(err, &numErr):
+		return numErr.Err.Error()
+	default:
+		return err.Error()
+	}
+}
+
+// WriteProblem writes the Problem describing err, an error returned by a
+// qs.Decoder, as an application/problem+json response. It is the error
+// handler of Bind.
+func WriteProblem(w http.ResponseWriter, r *http.Request, err error) {
+	problem := NewProblem(err)
+	w.Header().Set("Content-Type", ContentType)
+	w.WriteHeader(problem.Status)
+	_ = json.NewEncoder(w).Encode(problem)
+}
@@ -0,0 +1,55 @@
+package qshttp
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ohzqq/qs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewProblem(t *testing.T) {
+	test := assert.New(t)
+
+	type query struct {
+		Limit int      `query:"limit"`
+		Tags  []string `query:"tags"`
+	}
+
+	err := qs.NewDecoder(qs.WithMaxParams(2)).Decode("/?tags=a&tags=b&tags=c", &query{})
+	problem := NewProblem(err)
+	test.Equal(http.StatusBadRequest, problem.Status)
+	test.Equal("the request has too many parameters, the limit is 2", problem.Detail)
+	test.Empty(problem.InvalidParams)
+
+	err = qs.NewDecoder(qs.WithMaxValueLength(2)).Decode("/?tags=abc", &query{})
+	problem = NewProblem(err)
+	test.Equal(http.StatusBadRequest, problem.Status)
+	test.Equal([]InvalidParam{{Name: "tags", Reason: "qs: value length limit of 2 exceeded"}}, problem.InvalidParams)
+
+	err = qs.NewDecoder().Decode("/", &struct {
+		Limit int `query:"limit" validate:"unknown"`
+	}{})
+	problem = NewProblem(err)
+	test.Equal(&Problem{Type: "about:blank", Title: "Internal Server Error", Status: http.StatusInternalServerError}, problem)
+	test.Equal(http.StatusInternalServerError, NewProblem(errors.New("boom")).Status)
+
+	err = qs.NewDecoder().DecodeRequest(httptest.NewRequest(http.MethodGet, "/", nil), &struct {
+		Index string `path:"index"`
+	}{})
+	test.Equal(http.StatusInternalServerError, NewProblem(err).Status)
+
+	err = qs.NewDecoder(qs.WithRoute("/indexes/{index}")).Decode("/books", &struct {
+		Index string `path:"index"`
+	}{})
+	test.True(errors.Is(err, qs.ErrPathMismatch))
+	test.Equal(&Problem{Type: "about:blank", Title: "Not Found", Status: http.StatusNotFound}, NewProblem(err))
+
+	w := httptest.NewRecorder()
+	WriteProblem(w, httptest.NewRequest(http.MethodGet, "/", nil), errors.New("boom"))
+	test.Equal(http.StatusInternalServerError, w.Code)
+	test.Equal(ContentType, w.Header().Get("Content-Type"))
+	test.JSONEq(`{"type":"about:blank","title":"Internal Server Error","status":500}`, w.Body.String())
+}
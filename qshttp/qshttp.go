@@ -0,0 +1,102 @@
+// Package qshttp binds the path and query parameters of HTTP requests to
+// typed structs with a qs.Decoder.
+//
+//	type Search struct {
+//		Index string `path:"index"`
+//		Q     string `query:"q"`
+//		Limit int    `query:"limit" default:"20" validate:"max=100"`
+//	}
+//
+//	mux.Handle("GET /indexes/{index}/search", qshttp.Bind[Search](http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+//		search := qshttp.Params[Search](r)
+//		// ...
+//	})))
+//
+// Requests that fail to bind are answered with an RFC 7807 problem+json
+// response listing each invalid parameter.
+package qshttp
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/ohzqq/qs"
+)
+
+// Option provides option for Bind
+type Option func(config *config)
+
+type config struct {
+	decoderOptions []qs.DecoderOption
+	pathValues     func(r *http.Request) map[string]string
+	errorHandler   func(w http.ResponseWriter, r *http.Request, err error)
+}
+
+// WithDecoderOptions create a option to configure the qs.Decoder of Bind. The
+// decoder always collects errors, so the response lists every invalid
+// parameter.
+func WithDecoderOptions(options ...qs.DecoderOption) Option {
+	return func(config *config) {
+		config.decoderOptions = append(config.decoderOptions, options...)
+	}
+}
+
+// WithPathValues create a option to bind the values returned by pathValues,
+// the path parameters matched by the router, to the fields with a `path` tag.
+// Without it they are bound from the wildcards matched by http.ServeMux.
+func WithPathValues(pathValues func(r *http.Request) map[string]string) Option {
+	return func(config *config) {
+		config.pathValues = pathValues
+	}
+}
+
+// WithErrorHandler create a option to answer the requests that fail to bind
+// with handler instead of WriteProblem.
+func WithErrorHandler(handler func(w http.ResponseWriter, r *http.Request, err error)) Option {
+	return func(config *config) {
+		config.errorHandler = handler
+	}
+}
+
+type contextKey[T any] struct{}
+
+// Bind returns a handler decoding the path and query parameters of each
+// request into a T, which must be a struct, and calling next with the T
+// stored in the request context. Use Params or FromContext to retrieve it.
+// A request that fails to bind or to validate is answered by WriteProblem.
+func Bind[T any](next http.Handler, options ...Option) http.Handler {
+	config := &config{errorHandler: WriteProblem}
+	for _, opt := range options {
+		opt(config)
+	}
+	decoderOptions := append([]qs.DecoderOption{qs.WithCollectErrors()}, config.decoderOptions...)
+	decoder := qs.NewDecoder(decoderOptions...)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var params T
+		var err error
+		if config.pathValues != nil {
+			err = decoder.DecodeURL(r.URL, config.pathValues(r), &params)
+		} else {
+			err = decoder.DecodeRequest(r, &params)
+		}
+		if err != nil {
+			config.errorHandler(w, r, err)
+			return
+		}
+		ctx := context.WithValue(r.Context(), contextKey[T]{}, params)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// FromContext returns the T stored by Bind in ctx, and whether there is one.
+func FromContext[T any](ctx context.Context) (T, bool) {
+	params, ok := ctx.Value(contextKey[T]{}).(T)
+	return params, ok
+}
+
+// Params returns the T bound to r by Bind, the zero T when r wasn't.
+func Params[T any](r *http.Request) T {
+	params, _ := FromContext[T](r.Context())
+	return params
+}
@@ -0,0 +1,138 @@
+package qshttp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ohzqq/qs"
+	"github.com/stretchr/testify/assert"
+)
+
+type search struct {
+	Index string   `path:"index"`
+	Q     string   `query:"q"`
+	Limit int      `query:"limit" default:"20" validate:"min=1,max=100"`
+	Tags  []string `query:"tags,comma"`
+}
+
+func TestBind(t *testing.T) {
+	test := assert.New(t)
+
+	var got search
+	var bound bool
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = Params[search](r)
+		_, bound = FromContext[search](r.Context())
+		w.WriteHeader(http.StatusNoContent)
+	})
+	handler := Bind[search](next,
+		WithPathValues(func(r *http.Request) map[string]string {
+			return map[string]string{"index": strings.TrimPrefix(r.URL.Path, "/indexes/")}
+		}),
+		WithDecoderOptions(qs.WithMaxParams(10)),
+	)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/indexes/books?q=go&tags=a,b", nil))
+	test.Equal(http.StatusNoContent, w.Code)
+	test.True(bound)
+	test.Equal(search{Index: "books", Q: "go", Limit: 20, Tags: []string{"a", "b"}}, got)
+
+	// the decoder is shared by concurrent requests with their own path values
+	done := make(chan search)
+	for _, index := range []string{"a", "b", "c"} {
+		go func(index string) {
+			handler := Bind[search](http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				done <- Params[search](r)
+			}), WithPathValues(func(r *http.Request) map[string]string {
+				return map[string]string{"index": r.URL.Query().Get("index")}
+			}))
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/?index="+index, nil))
+		}(index)
+	}
+	indexes := map[string]bool{}
+	for i := 0; i < 3; i++ {
+		indexes[(<-done).Index] = true
+	}
+	test.Equal(map[string]bool{"a": true, "b": true, "c": true}, indexes)
+
+	// path values are bound from the wildcards matched by the ServeMux
+	mux := http.NewServeMux()
+	mux.Handle("GET /indexes/{index}/search", Bind[search](next))
+	mux.Handle("GET /search", Bind[search](next))
+	got = search{}
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/indexes/books/search?q=go", nil))
+	test.Equal(http.StatusNoContent, w.Code)
+	test.Equal(search{Index: "books", Q: "go", Limit: 20}, got)
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/search?q=go", nil))
+	test.Equal(http.StatusInternalServerError, w.Code)
+
+	_, ok := FromContext[search](context.Background())
+	test.False(ok)
+	test.Equal(search{}, Params[search](httptest.NewRequest(http.MethodGet, "/", nil)))
+}
+
+func TestBindProblem(t *testing.T) {
+	test := assert.New(t)
+
+	pathValues := WithPathValues(func(r *http.Request) map[string]string {
+		return map[string]string{"index": "books"}
+	})
+	called := false
+	handler := Bind[search](http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}), pathValues)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/?limit=x&q=go", nil))
+	test.False(called)
+	test.Equal(http.StatusBadRequest, w.Code)
+	test.Equal(ContentType, w.Header().Get("Content-Type"))
+	var problem Problem
+	test.NoError(json.Unmarshal(w.Body.Bytes(), &problem))
+	test.Equal(Problem{
+		Type:   "about:blank",
+		Title:  "Bad Request",
+		Status: http.StatusBadRequest,
+		Detail: "the request has an invalid parameter",
+		InvalidParams: []InvalidParam{
+			{Name: "limit", Value: "x", Reason: "invalid syntax"},
+		},
+	}, problem)
+
+	// every parameter is listed, validation failures included
+	handler = Bind[search](http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+		pathValues, WithDecoderOptions(qs.WithDisallowUnknownParams()))
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/?limit=101&qq=go", nil))
+	test.Equal(http.StatusBadRequest, w.Code)
+	var body map[string]interface{}
+	test.NoError(json.Unmarshal(w.Body.Bytes(), &body))
+	test.Equal("the request has 2 invalid parameters", body["detail"])
+	test.Equal([]interface{}{
+		map[string]interface{}{"name": "qq", "reason": `unknown parameter, did you mean "q"?`},
+		map[string]interface{}{"name": "limit", "value": "101", "reason": "must be at most 100"},
+	}, body["invalid-params"])
+
+	// an explicit zero is validated
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/?limit=0", nil))
+	test.Equal(http.StatusBadRequest, w.Code)
+	problem = Problem{}
+	test.NoError(json.Unmarshal(w.Body.Bytes(), &problem))
+	test.Equal([]InvalidParam{{Name: "limit", Value: "0", Reason: "must be at least 1"}}, problem.InvalidParams)
+
+	handler = Bind[search](http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+		WithErrorHandler(func(w http.ResponseWriter, r *http.Request, err error) {
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		}), pathValues)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/?limit=x", nil))
+	test.Equal(http.StatusUnprocessableEntity, w.Code)
+}
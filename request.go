@@ -0,0 +1,126 @@
+package qs
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// ErrMissingPathValue is the cause of the FieldError reported by
+// DecodeRequest for a field whose `path` tag names a wildcard the route
+// pattern of the request doesn't have.
+var ErrMissingPathValue = errors.New("missing path value")
+
+// DecodeRequest decodes the path values and the query of r to a destination
+// struct. The fields with a `path` tag are bound from r.PathValue, so the
+// wildcards matched by http.ServeMux are converted with the same rules as the
+// query parameters. A tagged name that isn't a wildcard of the route pattern,
+// or whose value is empty when r wasn't routed by a ServeMux, is reported as
+// a FieldError wrapping ErrMissingPathValue.
+func (d *Decoder) DecodeRequest(r *http.Request, dest any) error {
+	pathVals, err := d.binder.requestPathValues(r, dest)
+	if err != nil && !isFieldErrors(err) {
+		return err
+	}
+	return d.decode(r.URL, pathVals, err, dest)
+}
+
+func isFieldErrors(err error) bool {
+	switch err.(type) {
+	case *FieldError, FieldErrors:
+		return true
+	}
+	return false
+}
+
+// requestPathValues retrieves the path values of r bound to the fields of dest.
+func (b *DefaultBinder) requestPathValues(r *http.Request, dest any) (map[string]string, error) {
+	var wildcards map[string]bool
+	if r.Pattern != "" {
+		wildcards = patternWildcards(r.Pattern)
+	}
+	return b.lookupPathValues(dest, func(name string) (string, error) {
+		value := r.PathValue(name)
+		switch {
+		case wildcards != nil && !wildcards[name]:
+			return "", fmt.Errorf("%w, the route pattern %q has no {%s} wildcard", ErrMissingPathValue, r.Pattern, name)
+		case wildcards == nil && value == "":
+			return "", ErrMissingPathValue
+		default:
+			return value, nil
+		}
+	})
+}
+
+// lookupPathValues retrieves with lookup the path values bound to the fields
+// of dest. A value lookup fails to retrieve is reported as a FieldError
+// wrapping the error of lookup.
+func (b *DefaultBinder) lookupPathValues(dest any, lookup func(name string) (string, error)) (map[string]string, error) {
+	pathVals := make(map[string]string)
+	typ := reflect.TypeOf(dest)
+	if typ == nil {
+		return pathVals, nil
+	}
+	typ = derefType(typ)
+	if typ.Kind() != reflect.Struct {
+		return pathVals, nil
+	}
+	plan, err := b.plan(typ, b.pathTagName())
+	if err != nil {
+		return nil, err
+	}
+
+	var errs FieldErrors
+	walkValueFields(plan, "", func(fieldPlan *fieldPlan, path string) {
+		value, err := lookup(fieldPlan.name)
+		if err != nil {
+			errs = append(errs, &FieldError{Key: fieldPlan.name, Field: path, Err: err})
+			return
+		}
+		pathVals[fieldPlan.name] = value
+	})
+	switch {
+	case len(errs) == 0:
+		return pathVals, nil
+	case b.collectErrors:
+		return pathVals, errs
+	default:
+		return pathVals, errs[0]
+	}
+}
+
+// walkValueFields calls fn with each field of plan, and of its inline
+// structs, bound from a single parameter, and the Go path of the field.
+func walkValueFields(plan *structPlan, path string, fn func(fieldPlan *fieldPlan, path string)) {
+	for _, fieldPlan := range plan.fields {
+		switch fieldPlan.kind {
+		case planInline:
+			walkValueFields(fieldPlan.plan, path, fn)
+		case planValue:
+			fn(fieldPlan, fieldPlan.path(path))
+		}
+	}
+}
+
+// patternWildcards returns the names of the wildcards of a ServeMux pattern
+// like `GET /indexes/{index}/docs/{id...}`.
+func patternWildcards(pattern string) map[string]bool {
+	wildcards := make(map[string]bool)
+	for {
+		start := strings.IndexByte(pattern, '{')
+		if start < 0 {
+			return wildcards
+		}
+		end := strings.IndexByte(pattern[start:], '}')
+		if end < 0 {
+			return wildcards
+		}
+		name := strings.TrimSuffix(pattern[start+1:start+end], "...")
+		if name != "$" {
+			wildcards[name] = true
+		}
+		pattern = pattern[start+end+1:]
+	}
+}
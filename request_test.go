@@ -0,0 +1,94 @@
+package qs
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeRequest(t *testing.T) {
+	test := assert.New(t)
+
+	type Page struct {
+		Number int `path:"page"`
+	}
+	type doc struct {
+		Page
+		Index string    `path:"index" validate:"required"`
+		ID    int       `path:"id"`
+		Rest  string    `path:"rest"`
+		Sort  sortOrder `path:"sort"`
+		Q     string    `query:"q"`
+	}
+
+	var dest doc
+	var err error
+	mux := http.NewServeMux()
+	decoder := NewDecoder()
+	mux.HandleFunc("GET /indexes/{index}/docs/{id}/{page}/{sort}/{rest...}", func(w http.ResponseWriter, r *http.Request) {
+		dest = doc{}
+		err = decoder.DecodeRequest(r, &dest)
+	})
+	mux.HandleFunc("GET /indexes/{index}/{$}", func(w http.ResponseWriter, r *http.Request) {
+		dest = doc{}
+		err = decoder.DecodeRequest(r, &dest)
+	})
+
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/indexes/books/docs/42/3/desc/a/b?q=go", nil))
+	test.NoError(err)
+	test.Equal(doc{Page: Page{Number: 3}, Index: "books", ID: 42, Rest: "a/b", Sort: "desc", Q: "go"}, dest)
+
+	// an empty {rest...} wildcard is bound
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/indexes/books/docs/42/3/desc/", nil))
+	test.NoError(err)
+	test.Equal("", dest.Rest)
+
+	// path values are converted like query parameters
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/indexes/books/docs/x/3/desc/", nil))
+	var fieldErr *FieldError
+	if test.True(errors.As(err, &fieldErr)) {
+		test.Equal("id", fieldErr.Key)
+		test.Equal("x", fieldErr.Value)
+	}
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/indexes/books/docs/1/3/up/", nil))
+	if test.True(errors.As(err, &fieldErr)) {
+		test.Equal("sort", fieldErr.Key)
+	}
+
+	// a tagged wildcard missing from the pattern is reported
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/indexes/books/", nil))
+	test.True(errors.Is(err, ErrMissingPathValue))
+	test.EqualError(err, `qs: parameter "page" (field Number): missing path value, the route pattern "GET /indexes/{index}/{$}" has no {page} wildcard`)
+
+	decoder = NewDecoder(WithCollectErrors())
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/indexes/books/?q=go", nil))
+	var errs FieldErrors
+	if test.True(errors.As(err, &errs)) {
+		keys := make([]string, len(errs))
+		for i, err := range errs {
+			keys[i] = err.Key
+		}
+		test.Equal([]string{"page", "id", "rest", "sort"}, keys)
+	}
+	test.Equal("books", dest.Index)
+	test.Equal("go", dest.Q)
+
+	// without a pattern, the values set on the request are bound
+	r := httptest.NewRequest(http.MethodGet, "/?q=go", nil)
+	r.SetPathValue("index", "books")
+	r.SetPathValue("id", "1")
+	r.SetPathValue("page", "2")
+	r.SetPathValue("sort", "asc")
+	r.SetPathValue("rest", "x")
+	dest = doc{}
+	test.NoError(NewDecoder().DecodeRequest(r, &dest))
+	test.Equal(doc{Page: Page{Number: 2}, Index: "books", ID: 1, Rest: "x", Sort: "asc", Q: "go"}, dest)
+
+	r = httptest.NewRequest(http.MethodGet, "/", nil)
+	r.SetPathValue("index", "books")
+	err = NewDecoder().DecodeRequest(r, &doc{})
+	test.EqualError(err, `qs: parameter "page" (field Number): missing path value`)
+}
@@ -0,0 +1,209 @@
+package qs
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// ErrPathMismatch is returned by a Decoder created WithRoute for a URL whose
+// path doesn't match the route.
+var ErrPathMismatch = errors.New("path doesn't match the route")
+
+type routeSegmentKind uint8
+
+const (
+	// literal matches a segment equal to its text
+	routeLiteral routeSegmentKind = iota
+	// wildcard `{name}` matches a non empty segment
+	routeWildcard
+	// rest `{name...}` matches the remaining segments, the last one may be empty
+	routeRest
+	// prefix is the empty segment after a trailing slash, like `{...}` without a name
+	routePrefix
+	// end `{$}` matches the trailing slash of the path
+	routeEnd
+)
+
+type routeSegment struct {
+	kind routeSegmentKind
+	// text of a literal or name of a wildcard
+	text string
+}
+
+// route is a compiled route template like `/indexes/{index}/docs/{id...}`,
+// written like the patterns of http.ServeMux without method and host.
+type route struct {
+	template string
+	segments []routeSegment
+}
+
+// parseRoute compiles a route template.
+func parseRoute(template string) (*route, error) {
+	if !strings.HasPrefix(template, "/") {
+		return nil, fmt.Errorf("qs: route %q doesn't begin with a slash", template)
+	}
+	r := &route{template: template}
+	names := make(map[string]bool)
+	parts := strings.Split(template[1:], "/")
+	for i, part := range parts {
+		last := i == len(parts)-1
+		var segment routeSegment
+		switch {
+		case part == "":
+			if !last {
+				return nil, fmt.Errorf("qs: route %q has an empty segment", template)
+			}
+			segment.kind = routePrefix
+		case part == "{$}":
+			if !last {
+				return nil, fmt.Errorf("qs: route %q has {$} before its end", template)
+			}
+			segment.kind = routeEnd
+		case strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}"):
+			name := part[1 : len(part)-1]
+			segment.kind = routeWildcard
+			if strings.HasSuffix(name, "...") {
+				if !last {
+					return nil, fmt.Errorf("qs: route %q has %s before its end", template, part)
+				}
+				name = strings.TrimSuffix(name, "...")
+				segment.kind = routeRest
+			}
+			if name == "" || strings.ContainsAny(name, "{}") {
+				return nil, fmt.Errorf("qs: route %q has an invalid wildcard %s", template, part)
+			}
+			if names[name] {
+				return nil, fmt.Errorf("qs: route %q has the wildcard {%s} twice", template, name)
+			}
+			names[name] = true
+			segment.text = name
+		case strings.ContainsAny(part, "{}"):
+			return nil, fmt.Errorf("qs: route %q has a wildcard that isn't a whole segment: %s", template, part)
+		default:
+			literal, err := url.PathUnescape(part)
+			if err != nil {
+				return nil, fmt.Errorf("qs: route %q: %w", template, err)
+			}
+			segment.text = literal
+		}
+		r.segments = append(r.segments, segment)
+	}
+	return r, nil
+}
+
+// match returns the values of the wildcards of the route in the path of u,
+// unescaped, or an error wrapping ErrPathMismatch.
+func (r *route) match(u *url.URL) (map[string]string, error) {
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	mismatch := fmt.Errorf("qs: %w: path %q, route %q", ErrPathMismatch, path, r.template)
+	if path[0] != '/' {
+		return nil, mismatch
+	}
+
+	parts := strings.Split(path[1:], "/")
+	values := make(map[string]string)
+	for i, segment := range r.segments {
+		if i >= len(parts) {
+			return nil, mismatch
+		}
+		switch segment.kind {
+		case routePrefix:
+			return values, nil
+		case routeRest:
+			value, err := url.PathUnescape(strings.Join(parts[i:], "/"))
+			if err != nil {
+				return nil, mismatch
+			}
+			values[segment.text] = value
+			return values, nil
+		case routeEnd:
+			if i != len(parts)-1 || parts[i] != "" {
+				return nil, mismatch
+			}
+		case routeLiteral:
+			value, err := url.PathUnescape(parts[i])
+			if err != nil || value != segment.text {
+				return nil, mismatch
+			}
+		case routeWildcard:
+			value, err := url.PathUnescape(parts[i])
+			if err != nil || value == "" {
+				return nil, mismatch
+			}
+			values[segment.text] = value
+		}
+	}
+	if len(parts) != len(r.segments) {
+		return nil, mismatch
+	}
+	return values, nil
+}
+
+// wildcards returns the names of the wildcards of the route.
+func (r *route) wildcards() map[string]bool {
+	wildcards := make(map[string]bool)
+	for _, segment := range r.segments {
+		if segment.kind == routeWildcard || segment.kind == routeRest {
+			wildcards[segment.text] = true
+		}
+	}
+	return wildcards
+}
+
+// expand substitutes the path values into the route and returns the escaped
+// path, the path the route matches with the same values.
+func (r *route) expand(values map[string]string) (string, error) {
+	var path strings.Builder
+	for _, segment := range r.segments {
+		path.WriteByte('/')
+		switch segment.kind {
+		case routeLiteral:
+			path.WriteString(url.PathEscape(segment.text))
+		case routeWildcard:
+			value, ok := values[segment.text]
+			if !ok {
+				return "", fmt.Errorf("qs: %w, no field is bound to the wildcard {%s} of the route %q", ErrMissingPathValue, segment.text, r.template)
+			}
+			if value == "" {
+				return "", fmt.Errorf("qs: the wildcard {%s} of the route %q is empty", segment.text, r.template)
+			}
+			path.WriteString(url.PathEscape(value))
+		case routeRest:
+			value, ok := values[segment.text]
+			if !ok {
+				return "", fmt.Errorf("qs: %w, no field is bound to the wildcard {%s} of the route %q", ErrMissingPathValue, segment.text, r.template)
+			}
+			for i, part := range strings.Split(value, "/") {
+				if i > 0 {
+					path.WriteByte('/')
+				}
+				path.WriteString(url.PathEscape(part))
+			}
+		}
+	}
+	return path.String(), nil
+}
+
+// routePathValues matches the path of u against the route and retrieves the
+// path values bound to the fields of dest.
+func (d *Decoder) routePathValues(u *url.URL, dest any) (map[string]string, error) {
+	if d.routeErr != nil {
+		return nil, d.routeErr
+	}
+	matched, err := d.route.match(u)
+	if err != nil {
+		return nil, err
+	}
+	return d.binder.lookupPathValues(dest, func(name string) (string, error) {
+		value, ok := matched[name]
+		if !ok {
+			return "", fmt.Errorf("%w, the route %q has no {%s} wildcard", ErrMissingPathValue, d.route.template, name)
+		}
+		return value, nil
+	})
+}
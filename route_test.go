@@ -0,0 +1,121 @@
+package qs
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRoute(t *testing.T) {
+	test := assert.New(t)
+
+	tests := []struct {
+		template string
+		path     string
+		values   map[string]string
+	}{
+		{template: "/indexes/{index}", path: "/indexes/books", values: map[string]string{"index": "books"}},
+		{template: "/indexes/{index}", path: "/indexes/my%20books", values: map[string]string{"index": "my books"}},
+		{template: "/indexes/{index}", path: "/indexes/a%2Fb", values: map[string]string{"index": "a/b"}},
+		{template: "/indexes/{index}", path: "/indexes/"},
+		{template: "/indexes/{index}", path: "/indexes/books/docs"},
+		{template: "/indexes/{index}", path: "/indexes"},
+		{template: "/indexes/{index}", path: "/books/default"},
+		{template: "/indexes/{index}/docs/{id...}", path: "/indexes/books/docs/a/b%20c", values: map[string]string{"index": "books", "id": "a/b c"}},
+		{template: "/indexes/{index}/docs/{id...}", path: "/indexes/books/docs/", values: map[string]string{"index": "books", "id": ""}},
+		{template: "/indexes/{index}/docs/{id...}", path: "/indexes/books/docs"},
+		{template: "/indexes/{index}/", path: "/indexes/books/", values: map[string]string{"index": "books"}},
+		{template: "/indexes/{index}/", path: "/indexes/books/docs/1", values: map[string]string{"index": "books"}},
+		{template: "/indexes/{index}/", path: "/indexes/books"},
+		{template: "/indexes/{index}/{$}", path: "/indexes/books/", values: map[string]string{"index": "books"}},
+		{template: "/indexes/{index}/{$}", path: "/indexes/books/docs"},
+		{template: "/indexes/{index}/{$}", path: "/indexes/books"},
+		{template: "/", path: "", values: map[string]string{}},
+		{template: "/", path: "/indexes", values: map[string]string{}},
+		{template: "/a%20b/{c}", path: "/a%20b/d", values: map[string]string{"c": "d"}},
+	}
+	for _, tt := range tests {
+		r, err := parseRoute(tt.template)
+		if !test.NoError(err, tt.template) {
+			continue
+		}
+		u, err := url.Parse(tt.path)
+		if !test.NoError(err, tt.path) {
+			continue
+		}
+		values, err := r.match(u)
+		if tt.values == nil {
+			test.True(errors.Is(err, ErrPathMismatch), tt.template+" "+tt.path)
+			continue
+		}
+		test.NoError(err, tt.template+" "+tt.path)
+		test.Equal(tt.values, values, tt.template+" "+tt.path)
+	}
+
+	for _, template := range []string{
+		"indexes/{index}",
+		"/indexes//{index}",
+		"/indexes/{index...}/docs",
+		"/indexes/{$}/docs",
+		"/indexes/{}",
+		"/indexes/{...}",
+		"/indexes/{a{b}",
+		"/indexes/idx-{index}",
+		"/indexes/{index}/docs/{index}",
+	} {
+		_, err := parseRoute(template)
+		test.Error(err, template)
+	}
+}
+
+func TestDecodeWithRoute(t *testing.T) {
+	test := assert.New(t)
+
+	type doc struct {
+		Index string `path:"index"`
+		ID    string `path:"id"`
+		Q     string `query:"q"`
+	}
+
+	decoder := NewDecoder(WithRoute("/indexes/{index}/docs/{id...}"))
+	var dest doc
+	test.NoError(decoder.Decode("/indexes/books/docs/a/b?q=go", &dest))
+	test.Equal(doc{Index: "books", ID: "a/b", Q: "go"}, dest)
+
+	err := decoder.Decode("/indexes/books?q=go", &doc{})
+	test.True(errors.Is(err, ErrPathMismatch))
+	test.EqualError(err, `qs: path doesn't match the route: path "/indexes/books", route "/indexes/{index}/docs/{id...}"`)
+
+	// explicit path values take the place of the route
+	u, _ := url.Parse("/somewhere?q=go")
+	dest = doc{}
+	test.NoError(decoder.DecodeURL(u, map[string]string{"index": "a", "id": "b"}, &dest))
+	test.Equal(doc{Index: "a", ID: "b", Q: "go"}, dest)
+
+	// wildcards are converted like query parameters
+	type page struct {
+		Index string `path:"index"`
+		Page  int    `path:"page"`
+	}
+	pageDecoder := NewDecoder(WithRoute("/indexes/{index}/pages/{page}"), WithPathValues(map[string]string{"page": "9"}))
+	var p page
+	test.NoError(pageDecoder.Decode("/indexes/books/pages/2", &p))
+	test.Equal(page{Index: "books", Page: 2}, p)
+	err = pageDecoder.Decode("/indexes/books/pages/x", &page{})
+	var fieldErr *FieldError
+	if test.True(errors.As(err, &fieldErr)) {
+		test.Equal("page", fieldErr.Key)
+		test.Equal("x", fieldErr.Value)
+	}
+
+	// a path tag naming no wildcard is reported
+	err = NewDecoder(WithRoute("/indexes/{index}")).Decode("/indexes/books", &doc{})
+	test.True(errors.Is(err, ErrMissingPathValue))
+	test.EqualError(err, `qs: parameter "id" (field ID): missing path value, the route "/indexes/{index}" has no {id} wildcard`)
+
+	err = NewDecoder(WithRoute("/indexes/{index")).Decode("/indexes/books", &doc{})
+	test.Error(err)
+	test.False(errors.Is(err, ErrPathMismatch))
+}
@@ -0,0 +1,218 @@
+package qs
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// checkUnknown reports the parameters that don't bind to any field of plan,
+// sorted by name, suggesting the closest known parameter for each of them.
+func (b *DefaultBinder) checkUnknown(plan *structPlan, params *paramSet) error {
+	keys := make([]string, 0, len(params.data))
+	for k := range params.data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		name, segments, ok := splitKey(k)
+		if !ok {
+			if err := params.fail(&FieldError{Key: k, Err: &UnknownParamError{}}); err != nil {
+				return err
+			}
+			continue
+		}
+		if len(segments) == 0 && b.isNull(params.data[k]) && isNestedField(plan, name, params.fold) {
+			// the Encoder writes a nil pointer to a struct or a struct list as a null
+			continue
+		}
+		m := keyMatch{fold: params.fold}
+		if m.match(plan, 0, name, segments) {
+			continue
+		}
+		if err := params.fail(&FieldError{Key: k, Err: &UnknownParamError{Suggestion: m.suggest(name, segments)}}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isNestedField reports whether name is the name of a nested struct or
+// struct list field of plan.
+func isNestedField(plan *structPlan, name string, fold func(string) string) bool {
+	for _, field := range fieldsOf(plan, nil) {
+		if (field.kind == planNested || field.kind == planNestedList) && fold(field.name) == fold(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitKey splits a parameter name like `user[tags][]` into its name and its
+// bracketed segments.
+func splitKey(key string) (string, []string, bool) {
+	i := strings.IndexByte(key, '[')
+	if i < 0 {
+		return key, nil, true
+	}
+	name, rest := key[:i], key[i:]
+	var segments []string
+	for rest != "" {
+		end := strings.IndexByte(rest, ']')
+		if rest[0] != '[' || end < 0 {
+			return "", nil, false
+		}
+		segments = append(segments, rest[1:end])
+		rest = rest[end+1:]
+	}
+	return name, segments, true
+}
+
+// keyMatch follows a parameter name through the plans it would be bound by.
+// When it doesn't match, plan is the deepest struct plan it reached and depth
+// the index of the segment looked up in that plan, 0 being the name itself.
+type keyMatch struct {
+	fold  func(string) string
+	plan  *structPlan
+	depth int
+}
+
+// match reports whether the parameter made of name and segments binds to a
+// field of plan, name being the segment at depth.
+func (m *keyMatch) match(plan *structPlan, depth int, name string, segments []string) bool {
+	if m.plan == nil || depth >= m.depth {
+		m.plan, m.depth = plan, depth
+	}
+	for _, field := range fieldsOf(plan, nil) {
+		if m.fold(field.name) == m.fold(name) && m.matchField(field, depth, segments) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchField reports whether the segments following the name of field are
+// the ones its plan binds.
+func (m *keyMatch) matchField(field *fieldPlan, depth int, segments []string) bool {
+	switch field.kind {
+	case planNested:
+		return len(segments) > 0 && m.match(field.plan, depth+1, segments[0], segments[1:])
+	case planNestedList:
+		return len(segments) > 1 && isIndex(segments[0]) &&
+			m.match(field.plan, depth+2, segments[1], segments[2:])
+	case planMap:
+		if field.plan != nil {
+			return len(segments) > 1 && m.match(field.plan, depth+2, segments[1], segments[2:])
+		}
+		return len(segments) == 1 || len(segments) == 2 && segments[1] == ""
+	}
+
+	if !field.isList {
+		return len(segments) == 0
+	}
+	switch field.listFormat {
+	case arrayFormatBracket:
+		return len(segments) == 0 || len(segments) == 1 && segments[0] == ""
+	case arrayFormatIndex:
+		return len(segments) == 1 && isIndex(segments[0])
+	default:
+		return len(segments) == 0
+	}
+}
+
+// fieldsOf appends the fields of plan to fields, following inline structs.
+func fieldsOf(plan *structPlan, fields []*fieldPlan) []*fieldPlan {
+	for _, field := range plan.fields {
+		if field.kind == planInline {
+			fields = fieldsOf(field.plan, fields)
+			continue
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+// suggest returns the parameter name with the segment that didn't match
+// replaced by the closest candidate, or "" when none is close enough.
+func (m *keyMatch) suggest(name string, segments []string) string {
+	unknown := name
+	if m.depth > 0 {
+		unknown = segments[m.depth-1]
+	}
+	best, bestDistance := "", -1
+	for _, field := range fieldsOf(m.plan, nil) {
+		if m.fold(field.name) == m.fold(unknown) {
+			// the name is known, the segments following it are not
+			return ""
+		}
+		distance := editDistance(m.fold(unknown), m.fold(field.name))
+		if distance > maxSuggestionDistance(field.name) {
+			continue
+		}
+		if bestDistance < 0 || distance < bestDistance {
+			best, bestDistance = field.name, distance
+		}
+	}
+	if bestDistance < 0 {
+		return ""
+	}
+
+	var key strings.Builder
+	if m.depth == 0 {
+		key.WriteString(best)
+	} else {
+		key.WriteString(name)
+	}
+	for i, segment := range segments {
+		if i == m.depth-1 {
+			segment = best
+		}
+		key.WriteByte('[')
+		key.WriteString(segment)
+		key.WriteByte(']')
+	}
+	return key.String()
+}
+
+// maxSuggestionDistance is the largest edit distance between an unknown
+// parameter and name for name to be suggested.
+func maxSuggestionDistance(name string) int {
+	if len(name) < 8 {
+		return 1
+	}
+	return len(name) / 4
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a string, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = minInt(prev[j]+1, minInt(curr[j-1]+1, prev[j-1]+cost))
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+func isIndex(segment string) bool {
+	index, err := strconv.Atoi(segment)
+	return err == nil && index >= 0
+}
+
+func minInt(a int, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,94 @@
+package qs
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDisallowUnknownParams(t *testing.T) {
+	test := assert.New(t)
+
+	type Page struct {
+		Limit int `query:"limit"`
+	}
+	type item struct {
+		Name string `query:"name"`
+	}
+	type query struct {
+		Page
+		SrchAttr []string `query:"searchableAttributes"`
+		IDs      []int    `query:"ids,index"`
+		Tags     []string `query:"tags,bracket"`
+		User     struct {
+			From string `query:"from"`
+		} `query:"user"`
+		Items  []item            `query:"items"`
+		Filter map[string]string `query:"filter"`
+		Groups map[string]item   `query:"groups"`
+		Index  string            `path:"index"`
+	}
+
+	known := "/?limit=1&searchableAttributes=title&ids[0]=1&tags[]=a&tags=b&user[from]=me" +
+		"&items[0][name]=a&filter[status]=open&groups[a][name]=b&Limit=2"
+	decoder := NewDecoder(
+		WithPathValues(map[string]string{"index": "default"}),
+		WithDisallowUnknownParams(),
+	)
+	test.NoError(decoder.Decode(known, &query{}))
+
+	tests := []struct {
+		uri        string
+		suggestion string
+	}{
+		{uri: "/?serchableAttributes=title", suggestion: "searchableAttributes"},
+		{uri: "/?limt=1", suggestion: "limit"},
+		{uri: "/?user[frm]=me", suggestion: "user[from]"},
+		{uri: "/?items[0][nme]=a", suggestion: "items[0][name]"},
+		{uri: "/?groups[a][nam]=b", suggestion: "groups[a][name]"},
+		{uri: "/?ids=1"},
+		{uri: "/?tags[0]=a"},
+		{uri: "/?filter=open"},
+		{uri: "/?index=default"},
+		{uri: "/?offset=1"},
+		{uri: "/?user[from=me"},
+	}
+	for _, tt := range tests {
+		err := decoder.Decode(tt.uri, &query{})
+		var fieldErr *FieldError
+		var unknownErr *UnknownParamError
+		if test.True(errors.As(err, &fieldErr), tt.uri) && test.True(errors.As(err, &unknownErr), tt.uri) {
+			test.Equal(tt.suggestion, unknownErr.Suggestion, tt.uri)
+		}
+	}
+
+	err := decoder.Decode("/?serchableAttributes=title", &query{})
+	test.EqualError(err, `qs: parameter "serchableAttributes": unknown parameter, did you mean "searchableAttributes"?`)
+
+	// unknown parameters are sorted and come before conversion errors
+	collect := NewDecoder(WithDisallowUnknownParams(), WithCollectErrors())
+	err = collect.Decode("/?limit=x&zz=1&aa=2", &query{})
+	var errs FieldErrors
+	if test.True(errors.As(err, &errs)) && test.Len(errs, 3) {
+		test.Equal("aa", errs[0].Key)
+		test.Equal("zz", errs[1].Key)
+		test.Equal("limit", errs[2].Key)
+	}
+
+	// unknown parameters are ignored by default
+	test.NoError(NewDecoder().Decode("/?serchableAttributes=title", &query{}))
+
+	// parameter names are matched case sensitively with WithCaseSensitive
+	err = NewDecoder(WithDisallowUnknownParams(), WithCaseSensitive()).Decode("/?Limit=1", &query{})
+	var unknownErr *UnknownParamError
+	test.True(errors.As(err, &unknownErr))
+}
+
+func TestEditDistance(t *testing.T) {
+	test := assert.New(t)
+	test.Equal(0, editDistance("limit", "limit"))
+	test.Equal(1, editDistance("limt", "limit"))
+	test.Equal(2, editDistance("offest", "offset"))
+	test.Equal(5, editDistance("", "limit"))
+}
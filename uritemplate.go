@@ -0,0 +1,474 @@
+package qs
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// templateOperator is the expansion behavior of an RFC 6570 operator, see
+// appendix A of the RFC.
+type templateOperator struct {
+	first string
+	sep   string
+	named bool
+	// ifEmpty follows the name of an empty value
+	ifEmpty string
+	// allowReserved passes reserved characters and percent encoded triplets
+	allowReserved bool
+}
+
+var templateOperators = map[byte]*templateOperator{
+	0:   {first: "", sep: ","},
+	'+': {first: "", sep: ",", allowReserved: true},
+	'#': {first: "#", sep: ",", allowReserved: true},
+	'.': {first: ".", sep: "."},
+	'/': {first: "/", sep: "/"},
+	';': {first: ";", sep: ";", named: true},
+	'?': {first: "?", sep: "&", named: true, ifEmpty: "="},
+	'&': {first: "&", sep: "&", named: true, ifEmpty: "="},
+}
+
+type templateVarSpec struct {
+	name    string
+	explode bool
+	// prefix is the maximum length in characters of a string value, 0 is none
+	prefix int
+}
+
+// templatePart is a literal or an expression of a URI template
+type templatePart struct {
+	literal string
+	op      *templateOperator
+	vars    []templateVarSpec
+}
+
+// parseTemplate splits an RFC 6570 URI template into literals and expressions.
+func parseTemplate(template string) ([]templatePart, error) {
+	var parts []templatePart
+	rest := template
+	for rest != "" {
+		start := strings.IndexAny(rest, "{}")
+		if start < 0 {
+			parts = append(parts, templatePart{literal: rest})
+			break
+		}
+		if rest[start] == '}' {
+			return nil, fmt.Errorf("qs: template %q has an unopened }", template)
+		}
+		if start > 0 {
+			parts = append(parts, templatePart{literal: rest[:start]})
+		}
+		end := strings.IndexByte(rest[start:], '}')
+		if end < 0 {
+			return nil, fmt.Errorf("qs: template %q has an unclosed {", template)
+		}
+		part, err := parseExpression(rest[start+1 : start+end])
+		if err != nil {
+			return nil, fmt.Errorf("qs: template %q: %w", template, err)
+		}
+		parts = append(parts, part)
+		rest = rest[start+end+1:]
+	}
+	return parts, nil
+}
+
+// parseExpression parses the text of an expression between braces.
+func parseExpression(expr string) (templatePart, error) {
+	var part templatePart
+	var opChar byte
+	if expr != "" {
+		switch c := expr[0]; c {
+		case '+', '#', '.', '/', ';', '?', '&':
+			opChar, expr = c, expr[1:]
+		case '=', ',', '!', '@', '|':
+			return part, fmt.Errorf("reserved operator %c", c)
+		}
+	}
+	part.op = templateOperators[opChar]
+
+	for _, text := range strings.Split(expr, ",") {
+		var spec templateVarSpec
+		if name, ok := strings.CutSuffix(text, "*"); ok {
+			text, spec.explode = name, true
+		} else if name, prefix, ok := strings.Cut(text, ":"); ok {
+			n, err := strconv.Atoi(prefix)
+			if err != nil || n < 1 || n > 9999 || prefix[0] == '0' || prefix[0] == '+' {
+				return part, fmt.Errorf("invalid prefix %q", prefix)
+			}
+			text, spec.prefix = name, n
+		}
+		if !isTemplateVarName(text) {
+			return part, fmt.Errorf("invalid variable name %q", text)
+		}
+		spec.name = text
+		part.vars = append(part.vars, spec)
+	}
+	return part, nil
+}
+
+// isTemplateVarName reports whether name is a varname of RFC 6570, varchars
+// separated by single dots.
+func isTemplateVarName(name string) bool {
+	if name == "" || name[0] == '.' || name[len(name)-1] == '.' || strings.Contains(name, "..") {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		switch c := name[i]; {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9', c == '_', c == '.':
+		case c == '%' && i+2 < len(name) && isHex(name[i+1]) && isHex(name[i+2]):
+			i += 2
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+func isUnreserved(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' ||
+		c == '-' || c == '.' || c == '_' || c == '~'
+}
+
+func isReserved(c byte) bool {
+	return strings.IndexByte(":/?#[]@!$&'()*+,;=", c) >= 0
+}
+
+// writeTemplateEscaped writes s percent encoding the characters that aren't
+// unreserved, or reserved when allowReserved.
+func writeTemplateEscaped(buf *strings.Builder, s string, allowReserved bool) {
+	const hex = "0123456789ABCDEF"
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case isUnreserved(c):
+			buf.WriteByte(c)
+		case allowReserved && c == '%' && i+2 < len(s) && isHex(s[i+1]) && isHex(s[i+2]):
+			buf.WriteString(s[i : i+3])
+			i += 2
+		case allowReserved && isReserved(c):
+			buf.WriteByte(c)
+		default:
+			buf.WriteByte('%')
+			buf.WriteByte(hex[c>>4])
+			buf.WriteByte(hex[c&15])
+		}
+	}
+}
+
+type templateValueKind uint8
+
+const (
+	templateUndefined templateValueKind = iota
+	templateString
+	templateList
+	templateAssoc
+	// templateCommaList is a list encoded as a single comma separated value
+	templateCommaList
+	// templateMap is an associative array sorted by key
+	templateMap
+	// templateDynamic is an interface, a string or a list by its values
+	templateDynamic
+)
+
+// templateValue is the value of a template variable. items holds the string,
+// the list, or the keys and values of an associative array in turn.
+type templateValue struct {
+	kind  templateValueKind
+	items []string
+}
+
+// templateVars resolves the variables of a template from the parameters of
+// an encoded struct.
+type templateVars struct {
+	values url.Values
+	// names of the parameters in encoding order
+	names []string
+	// kinds of the variables by the Go kind of their fields
+	kinds map[string]templateValueKind
+}
+
+// lookup returns the value of the variable name. Lists are the values of
+// their parameters in any list format, maps and nested structs associative
+// arrays of their `name[key]` parameters, sorted by key for maps and in field
+// order for structs.
+func (vars *templateVars) lookup(name string) templateValue {
+	switch kind := vars.kinds[name]; kind {
+	case templateString:
+		values := vars.values[name]
+		if len(values) == 0 {
+			return templateValue{}
+		}
+		return templateValue{kind: templateString, items: values[:1]}
+	case templateList, templateCommaList:
+		var items []string
+		for _, param := range vars.params(name) {
+			if key, ok := subKey(param, name); param != name && !(ok && (key == "" || isIndex(key))) {
+				continue
+			}
+			for _, v := range vars.values[param] {
+				if kind == templateCommaList {
+					items = append(items, strings.Split(v, ",")...)
+					continue
+				}
+				items = append(items, v)
+			}
+		}
+		if len(items) == 0 {
+			return templateValue{}
+		}
+		return templateValue{kind: templateList, items: items}
+	case templateAssoc, templateMap:
+		type pair struct{ key, value string }
+		var pairs []pair
+		for _, param := range vars.params(name) {
+			key, ok := subKey(param, name)
+			if !ok {
+				continue
+			}
+			for _, v := range vars.values[param] {
+				pairs = append(pairs, pair{key: key, value: v})
+			}
+		}
+		if len(pairs) == 0 {
+			return templateValue{}
+		}
+		if kind == templateMap {
+			sort.SliceStable(pairs, func(i, j int) bool { return pairs[i].key < pairs[j].key })
+		}
+		value := templateValue{kind: templateAssoc, items: make([]string, 0, 2*len(pairs))}
+		for _, p := range pairs {
+			value.items = append(value.items, p.key, p.value)
+		}
+		return value
+	case templateDynamic:
+		switch values := vars.values[name]; len(values) {
+		case 0:
+			return templateValue{}
+		case 1:
+			return templateValue{kind: templateString, items: values}
+		default:
+			return templateValue{kind: templateList, items: values}
+		}
+	default:
+		return templateValue{}
+	}
+}
+
+// params returns the distinct names of the parameters of the variable name,
+// name itself and `name[...]`, in encoding order.
+func (vars *templateVars) params(name string) []string {
+	var params []string
+	seen := make(map[string]bool)
+	for _, param := range vars.names {
+		if seen[param] || param != name && !strings.HasPrefix(param, name+"[") {
+			continue
+		}
+		seen[param] = true
+		params = append(params, param)
+	}
+	return params
+}
+
+// subKey returns key of a parameter `name[key]`, ok is false for other
+// parameters, name included.
+func subKey(param string, name string) (key string, ok bool) {
+	if len(param) < len(name)+2 || param[len(param)-1] != ']' {
+		return "", false
+	}
+	key = param[len(name)+1 : len(param)-1]
+	return key, !strings.ContainsAny(key, "[]")
+}
+
+// templateKinds returns the kinds of the variables of the fields of the
+// struct type typ, named like the parameters they are encoded to.
+func (e *encoder) templateKinds(typ reflect.Type) map[string]templateValueKind {
+	kinds := make(map[string]templateValueKind, typ.NumField())
+	for i := 0; i < typ.NumField(); i++ {
+		structField := typ.Field(i)
+		if structField.PkgPath != "" && !structField.Anonymous {
+			continue
+		}
+		if e.e.isPathField(structField) {
+			e.getTagNameAndOptsOf(structField, e.e.pathTag)
+		} else {
+			e.getTagNameAndOpts(structField)
+		}
+		name := string(e.tags[0])
+		if name == "-" {
+			continue
+		}
+
+		fieldTyp := derefType(structField.Type)
+		if isPresenceType(fieldTyp) {
+			fieldTyp = derefType(presenceElemType(fieldTyp))
+		}
+		switch {
+		case fieldTyp == timeType || e.e.isCustomType(fieldTyp, e.tags[1:]):
+			kinds[name] = templateString
+		case fieldTyp.Kind() == reflect.Slice || fieldTyp.Kind() == reflect.Array:
+			kinds[name] = templateList
+			if hasTagOption(e.tags[1:], "comma") {
+				kinds[name] = templateCommaList
+			}
+		case fieldTyp.Kind() == reflect.Map:
+			kinds[name] = templateMap
+		case fieldTyp.Kind() == reflect.Struct:
+			kinds[name] = templateAssoc
+		case fieldTyp.Kind() == reflect.Interface:
+			kinds[name] = templateDynamic
+		default:
+			kinds[name] = templateString
+		}
+	}
+	return kinds
+}
+
+// Expand expands the RFC 6570 URI template with the fields of the struct v,
+// up to level 4: every operator, and the explode `*` and prefix `:n`
+// modifiers.
+//
+// The variables are the parameters v encodes to, named and formatted like
+// Values does, the fields with a `path` tag included. Slices and arrays are
+// lists, maps are associative arrays sorted by key and nested structs
+// associative arrays in field order, other fields are strings. Omitted
+// fields, nil lists and bare keys are undefined.
+func (e *Encoder) Expand(template string, v interface{}) (string, error) {
+	parts, err := parseTemplate(template)
+	if err != nil {
+		return "", err
+	}
+	val, err := structValue(v)
+	if err != nil {
+		return "", err
+	}
+
+	vars := &templateVars{values: make(url.Values)}
+	enc := e.dataPool.Get().(*encoder)
+	enc.pathValues, enc.names = vars.values, make([]string, 0, val.NumField())
+	err = enc.encodeStruct(val, vars.values, nil)
+	vars.names = enc.names
+	if err == nil {
+		vars.kinds = enc.templateKinds(val.Type())
+	}
+	enc.pathValues, enc.names = nil, nil
+	e.dataPool.Put(enc)
+	if err != nil {
+		return "", err
+	}
+
+	var buf strings.Builder
+	for _, part := range parts {
+		if part.op == nil {
+			writeTemplateEscaped(&buf, part.literal, true)
+			continue
+		}
+		expandExpression(&buf, part, vars)
+	}
+	return buf.String(), nil
+}
+
+// expandExpression writes the expansion of an expression, following the
+// algorithm of appendix A of RFC 6570.
+func expandExpression(buf *strings.Builder, part templatePart, vars *templateVars) {
+	op := part.op
+	first := true
+	for _, spec := range part.vars {
+		value := vars.lookup(spec.name)
+		if value.kind == templateUndefined {
+			continue
+		}
+		if first {
+			buf.WriteString(op.first)
+			first = false
+		} else {
+			buf.WriteString(op.sep)
+		}
+
+		switch {
+		case value.kind == templateString:
+			s := value.items[0]
+			if op.named {
+				buf.WriteString(spec.name)
+				if s == "" {
+					buf.WriteString(op.ifEmpty)
+					continue
+				}
+				buf.WriteByte('=')
+			}
+			if spec.prefix > 0 {
+				s = prefixChars(s, spec.prefix)
+			}
+			writeTemplateEscaped(buf, s, op.allowReserved)
+		case !spec.explode:
+			if op.named {
+				buf.WriteString(spec.name)
+				buf.WriteByte('=')
+			}
+			for i, item := range value.items {
+				if i > 0 {
+					buf.WriteByte(',')
+				}
+				writeTemplateEscaped(buf, item, op.allowReserved)
+			}
+		case value.kind == templateList:
+			for i, item := range value.items {
+				if i > 0 {
+					buf.WriteString(op.sep)
+				}
+				if op.named {
+					writeTemplatePair(buf, op, spec.name, item)
+					continue
+				}
+				writeTemplateEscaped(buf, item, op.allowReserved)
+			}
+		default:
+			for i := 0; i < len(value.items); i += 2 {
+				if i > 0 {
+					buf.WriteString(op.sep)
+				}
+				if op.named {
+					writeTemplatePair(buf, op, value.items[i], value.items[i+1])
+					continue
+				}
+				writeTemplateEscaped(buf, value.items[i], op.allowReserved)
+				buf.WriteByte('=')
+				writeTemplateEscaped(buf, value.items[i+1], op.allowReserved)
+			}
+		}
+	}
+}
+
+// writeTemplatePair writes `name=value` of an exploded value of a named
+// operator, or name followed by ifEmpty when value is empty.
+func writeTemplatePair(buf *strings.Builder, op *templateOperator, name string, value string) {
+	writeTemplateEscaped(buf, name, op.allowReserved)
+	if value == "" {
+		buf.WriteString(op.ifEmpty)
+		return
+	}
+	buf.WriteByte('=')
+	writeTemplateEscaped(buf, value, op.allowReserved)
+}
+
+// prefixChars returns the first n characters of s.
+func prefixChars(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	for i := range s {
+		if n == 0 {
+			return s[:i]
+		}
+		n--
+	}
+	return s
+}
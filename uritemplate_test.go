@@ -0,0 +1,277 @@
+package qs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// specVars are the variables of the examples of RFC 6570 section 3.2
+type specVars struct {
+	Count []string `query:"count"`
+	Dom   []string `query:"dom"`
+	Dub   string   `query:"dub"`
+	Hello string   `query:"hello"`
+	Half  string   `query:"half"`
+	Var   string   `query:"var"`
+	Who   string   `query:"who"`
+	Base  string   `query:"base"`
+	Path  string   `query:"path"`
+	List  []string `query:"list"`
+	Keys  struct {
+		Semi  string `query:"semi"`
+		Dot   string `query:"dot"`
+		Comma string `query:"comma"`
+	} `query:"keys"`
+	V         string            `query:"v"`
+	X         string            `query:"x"`
+	Y         string            `query:"y"`
+	Empty     string            `query:"empty"`
+	EmptyKeys map[string]string `query:"empty_keys"`
+	Undef     *string           `query:"undef,omitempty"`
+}
+
+func TestExpandSpecExamples(t *testing.T) {
+	test := assert.New(t)
+
+	vars := specVars{
+		Count: []string{"one", "two", "three"},
+		Dom:   []string{"example", "com"},
+		Dub:   "me/too",
+		Hello: "Hello World!",
+		Half:  "50%",
+		Var:   "value",
+		Who:   "fred",
+		Base:  "http://example.com/home/",
+		Path:  "/foo/bar",
+		List:  []string{"red", "green", "blue"},
+		V:     "6",
+		X:     "1024",
+		Y:     "768",
+	}
+	vars.Keys.Semi, vars.Keys.Dot, vars.Keys.Comma = ";", ".", ","
+
+	tests := map[string]string{
+		// 3.2.1 Variable Expansion
+		"{count}":   "one,two,three",
+		"{count*}":  "one,two,three",
+		"{/count}":  "/one,two,three",
+		"{/count*}": "/one/two/three",
+		"{;count}":  ";count=one,two,three",
+		"{;count*}": ";count=one;count=two;count=three",
+		"{?count}":  "?count=one,two,three",
+		"{?count*}": "?count=one&count=two&count=three",
+		"{&count*}": "&count=one&count=two&count=three",
+		// 3.2.2 Simple String Expansion
+		"{var}":       "value",
+		"{hello}":     "Hello%20World%21",
+		"{half}":      "50%25",
+		"O{empty}X":   "OX",
+		"O{undef}X":   "OX",
+		"{x,y}":       "1024,768",
+		"{x,hello,y}": "1024,Hello%20World%21,768",
+		"?{x,empty}":  "?1024,",
+		"?{x,undef}":  "?1024",
+		"?{undef,y}":  "?768",
+		"{var:3}":     "val",
+		"{var:30}":    "value",
+		"{list}":      "red,green,blue",
+		"{list*}":     "red,green,blue",
+		"{keys}":      "semi,%3B,dot,.,comma,%2C",
+		"{keys*}":     "semi=%3B,dot=.,comma=%2C",
+		// 3.2.3 Reserved Expansion
+		"{+var}":              "value",
+		"{+hello}":            "Hello%20World!",
+		"{+half}":             "50%25",
+		"{base}index":         "http%3A%2F%2Fexample.com%2Fhome%2Findex",
+		"{+base}index":        "http://example.com/home/index",
+		"O{+empty}X":          "OX",
+		"O{+undef}X":          "OX",
+		"{+path}/here":        "/foo/bar/here",
+		"here?ref={+path}":    "here?ref=/foo/bar",
+		"up{+path}{var}/here": "up/foo/barvalue/here",
+		"{+x,hello,y}":        "1024,Hello%20World!,768",
+		"{+path,x}/here":      "/foo/bar,1024/here",
+		"{+path:6}/here":      "/foo/b/here",
+		"{+list}":             "red,green,blue",
+		"{+list*}":            "red,green,blue",
+		"{+keys}":             "semi,;,dot,.,comma,,",
+		"{+keys*}":            "semi=;,dot=.,comma=,",
+		// 3.2.4 Fragment Expansion
+		"{#var}":         "#value",
+		"{#hello}":       "#Hello%20World!",
+		"{#half}":        "#50%25",
+		"foo{#empty}":    "foo#",
+		"foo{#undef}":    "foo",
+		"{#x,hello,y}":   "#1024,Hello%20World!,768",
+		"{#path,x}/here": "#/foo/bar,1024/here",
+		"{#path:6}/here": "#/foo/b/here",
+		"{#list}":        "#red,green,blue",
+		"{#list*}":       "#red,green,blue",
+		"{#keys}":        "#semi,;,dot,.,comma,,",
+		"{#keys*}":       "#semi=;,dot=.,comma=,",
+		// 3.2.5 Label Expansion with Dot-Prefix
+		"{.who}":          ".fred",
+		"{.who,who}":      ".fred.fred",
+		"{.half,who}":     ".50%25.fred",
+		"www{.dom*}":      "www.example.com",
+		"X{.var}":         "X.value",
+		"X{.empty}":       "X.",
+		"X{.undef}":       "X",
+		"X{.var:3}":       "X.val",
+		"X{.list}":        "X.red,green,blue",
+		"X{.list*}":       "X.red.green.blue",
+		"X{.keys}":        "X.semi,%3B,dot,.,comma,%2C",
+		"X{.keys*}":       "X.semi=%3B.dot=..comma=%2C",
+		"X{.empty_keys}":  "X",
+		"X{.empty_keys*}": "X",
+		// 3.2.6 Path Segment Expansion
+		"{/who}":          "/fred",
+		"{/who,who}":      "/fred/fred",
+		"{/half,who}":     "/50%25/fred",
+		"{/who,dub}":      "/fred/me%2Ftoo",
+		"{/var}":          "/value",
+		"{/var,empty}":    "/value/",
+		"{/var,undef}":    "/value",
+		"{/var,x}/here":   "/value/1024/here",
+		"{/var:1,var}":    "/v/value",
+		"{/list}":         "/red,green,blue",
+		"{/list*}":        "/red/green/blue",
+		"{/list*,path:4}": "/red/green/blue/%2Ffoo",
+		"{/keys}":         "/semi,%3B,dot,.,comma,%2C",
+		"{/keys*}":        "/semi=%3B/dot=./comma=%2C",
+		// 3.2.7 Path-Style Parameter Expansion
+		"{;who}":         ";who=fred",
+		"{;half}":        ";half=50%25",
+		"{;empty}":       ";empty",
+		"{;v,empty,who}": ";v=6;empty;who=fred",
+		"{;v,bar,who}":   ";v=6;who=fred",
+		"{;x,y}":         ";x=1024;y=768",
+		"{;x,y,empty}":   ";x=1024;y=768;empty",
+		"{;x,y,undef}":   ";x=1024;y=768",
+		"{;hello:5}":     ";hello=Hello",
+		"{;list}":        ";list=red,green,blue",
+		"{;list*}":       ";list=red;list=green;list=blue",
+		"{;keys}":        ";keys=semi,%3B,dot,.,comma,%2C",
+		"{;keys*}":       ";semi=%3B;dot=.;comma=%2C",
+		// 3.2.8 Form-Style Query Expansion
+		"{?who}":       "?who=fred",
+		"{?half}":      "?half=50%25",
+		"{?x,y}":       "?x=1024&y=768",
+		"{?x,y,empty}": "?x=1024&y=768&empty=",
+		"{?x,y,undef}": "?x=1024&y=768",
+		"{?var:3}":     "?var=val",
+		"{?list}":      "?list=red,green,blue",
+		"{?list*}":     "?list=red&list=green&list=blue",
+		"{?keys}":      "?keys=semi,%3B,dot,.,comma,%2C",
+		"{?keys*}":     "?semi=%3B&dot=.&comma=%2C",
+		// 3.2.9 Form-Style Query Continuation
+		"{&who}":         "&who=fred",
+		"{&half}":        "&half=50%25",
+		"?fixed=yes{&x}": "?fixed=yes&x=1024",
+		"{&x,y,empty}":   "&x=1024&y=768&empty=",
+		"{&var:3}":       "&var=val",
+		"{&list}":        "&list=red,green,blue",
+		"{&list*}":       "&list=red&list=green&list=blue",
+		"{&keys}":        "&keys=semi,%3B,dot,.,comma,%2C",
+		"{&keys*}":       "&semi=%3B&dot=.&comma=%2C",
+	}
+
+	encoder := NewEncoder()
+	for template, expected := range tests {
+		actual, err := encoder.Expand(template, &vars)
+		test.NoError(err, template)
+		test.Equal(expected, actual, template)
+	}
+}
+
+func TestExpand(t *testing.T) {
+	test := assert.New(t)
+
+	type search struct {
+		Index string            `path:"index"`
+		Q     string            `query:"q"`
+		Tags  []string          `query:"tags"`
+		Page  *int              `query:"page,omitempty"`
+		Debug bool              `query:"debug,flag"`
+		Sort  map[string]string `query:"sort"`
+		Café  string            `query:"cafe"`
+	}
+
+	encoder := NewEncoder()
+	page := 2
+	actual, err := encoder.Expand("/indexes/{index}/search{?q,tags*,page,debug}", search{
+		Index: "my books",
+		Q:     "go & rust",
+		Tags:  []string{"a", "b"},
+		Page:  &page,
+	})
+	test.NoError(err)
+	test.Equal("/indexes/my%20books/search?q=go%20%26%20rust&tags=a&tags=b&page=2", actual)
+
+	// flags are bare keys, which are undefined
+	actual, err = encoder.Expand("/search{?q,debug}", search{Q: "go", Debug: true})
+	test.NoError(err)
+	test.Equal("/search?q=go", actual)
+
+	// prefixes count characters, literals keep percent encoded triplets
+	actual, err = encoder.Expand("/caf%C3%A9 ü/{cafe:4}", search{Café: "crème brûlée"})
+	test.NoError(err)
+	test.Equal("/caf%C3%A9%20%C3%BC/cr%C3%A8m", actual)
+
+	actual, err = encoder.Expand("{?sort*}", search{Sort: map[string]string{"name": "asc"}})
+	test.NoError(err)
+	test.Equal("?name=asc", actual)
+
+	actual, err = encoder.Expand("{;sort}", search{Sort: map[string]string{"name": "asc"}})
+	test.NoError(err)
+	test.Equal(";sort=name,asc", actual)
+
+	// maps are sorted by key
+	order := map[string]string{"name": "asc", "date": "desc", "rank": "asc", "id": "desc"}
+	for i := 0; i < 20; i++ {
+		actual, err = encoder.Expand("{?sort*}{;sort}", search{Sort: order})
+		test.NoError(err)
+		test.Equal("?date=desc&id=desc&name=asc&rank=asc;sort=date,desc,id,desc,name,asc,rank,asc", actual)
+	}
+
+	// a list of one element is a list, prefixes apply to strings only
+	actual, err = encoder.Expand("{?tags:1}{&q:1}", search{Tags: []string{"ab"}, Q: "ab"})
+	test.NoError(err)
+	test.Equal("?tags=ab&q=a", actual)
+
+	type formats struct {
+		Bracket []string `query:"bracket,bracket"`
+		Index   []int    `query:"index,index"`
+		Comma   []string `query:"comma,comma"`
+	}
+	actual, err = encoder.Expand("{/bracket*}{/index*}{?comma}", formats{
+		Bracket: []string{"a", "b"},
+		Index:   []int{1, 2},
+		Comma:   []string{"c", "d"},
+	})
+	test.NoError(err)
+	test.Equal("/a/b/1/2?comma=c,d", actual)
+
+	for _, template := range []string{
+		"/search{?q",
+		"/search}",
+		"/search{}",
+		"/search{=q}",
+		"/search{|q}",
+		"/search{q:0}",
+		"/search{q:10000}",
+		"/search{q:x}",
+		"/search{q*:3}",
+		"/search{.q.}",
+		"/search{q..x}",
+		"/search{q-x}",
+		"/search{q,}",
+	} {
+		_, err := encoder.Expand(template, search{})
+		test.Error(err, template)
+	}
+
+	_, err = encoder.Expand("{q}", "q")
+	test.Error(err)
+}
@@ -0,0 +1,89 @@
+package qs
+
+import (
+	"net/url"
+	"reflect"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// URL builds the URL of the struct v, the inverse of decoding it with a
+// Decoder created WithRoute. The route template, written like the route of
+// WithRoute, is appended to the path of base, its wildcards are substituted
+// with the escaped values of the fields with a `path` tag and no query tag.
+// The other fields are encoded as the query, after the query of base.
+//
+// A wildcard no field is bound to returns an error wrapping
+// ErrMissingPathValue, as does a path field the route has no wildcard for.
+// The value of a `{name}` wildcard can't be empty.
+func (e *Encoder) URL(base string, template string, v interface{}) (*url.URL, error) {
+	r, err := parseRoute(template)
+	if err != nil {
+		return nil, err
+	}
+	u, err := url.Parse(base)
+	if err != nil {
+		return nil, err
+	}
+
+	val, err := structValue(v)
+	if err != nil {
+		return nil, err
+	}
+
+	values, pathValues := make(url.Values), make(url.Values)
+	enc := e.dataPool.Get().(*encoder)
+	enc.pathValues = pathValues
+	err = enc.encodeStruct(val, values, nil)
+	enc.pathValues = nil
+	e.dataPool.Put(enc)
+	if err != nil {
+		return nil, err
+	}
+
+	wildcards := r.wildcards()
+	names := make(map[string]string, len(pathValues))
+	for name, vals := range pathValues {
+		if !wildcards[name] {
+			return nil, errors.Wrapf(ErrMissingPathValue, "qs: the route %q has no {%s} wildcard", template, name)
+		}
+		if len(vals) != 1 {
+			return nil, errors.Errorf("qs: path parameter %q has %d values, expects one", name, len(vals))
+		}
+		names[name] = vals[0]
+	}
+
+	path, err := r.expand(names)
+	if err != nil {
+		return nil, err
+	}
+	rawPath := strings.TrimSuffix(u.EscapedPath(), "/") + path
+	if u.Path, err = url.PathUnescape(rawPath); err != nil {
+		return nil, err
+	}
+	u.RawPath = rawPath
+
+	if query := EncodeValues(values); query != "" {
+		if u.RawQuery != "" {
+			u.RawQuery += "&"
+		}
+		u.RawQuery += query
+	}
+	return u, nil
+}
+
+// structValue returns the struct v points to.
+func structValue(v interface{}) (reflect.Value, error) {
+	val := reflect.ValueOf(v)
+	for val.Kind() == reflect.Ptr {
+		if val.IsNil() {
+			return reflect.Value{}, errors.Errorf("expects struct input, got %v", val.Kind())
+		}
+		val = val.Elem()
+	}
+	if val.Kind() != reflect.Struct {
+		return reflect.Value{}, errors.Errorf("expects struct input, got %v", val.Kind())
+	}
+	return val, nil
+}
@@ -0,0 +1,79 @@
+package qs
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncoderURL(t *testing.T) {
+	test := assert.New(t)
+
+	type doc struct {
+		Index string   `path:"index"`
+		ID    string   `path:"id"`
+		Q     string   `query:"q"`
+		Tags  []string `query:"tags"`
+	}
+
+	encoder := NewEncoder()
+	in := doc{Index: "my books", ID: "2024/go 101", Q: "a&b", Tags: []string{"x", "y"}}
+	u, err := encoder.URL("https://example.com/v1/", "/indexes/{index}/docs/{id...}", in)
+	test.NoError(err)
+	test.Equal("https://example.com/v1/indexes/my%20books/docs/2024/go%20101?q=a%26b&tags=x&tags=y", u.String())
+
+	// the URL decodes back to the struct
+	var out doc
+	decoder := NewDecoder(WithRoute("/v1/indexes/{index}/docs/{id...}"))
+	test.NoError(decoder.Decode(u.String(), &out))
+	test.Equal(in, out)
+
+	// path fields are not query parameters
+	values, err := encoder.Values(in)
+	test.NoError(err)
+	test.Equal(url.Values{"q": {"a&b"}, "tags": {"x", "y"}}, values)
+
+	// a slash in a single segment is escaped
+	u, err = encoder.URL("https://example.com?lang=en", "/indexes/{index}/docs/{id}", doc{Index: "a/b", ID: "1"})
+	test.NoError(err)
+	test.Equal("https://example.com/indexes/a%2Fb/docs/1?lang=en&q=", u.String())
+	out = doc{}
+	test.NoError(NewDecoder(WithRoute("/indexes/{index}/docs/{id}")).Decode(u.String(), &out))
+	test.Equal(doc{Index: "a/b", ID: "1"}, out)
+
+	type page struct {
+		Index string `path:"index"`
+		Page  *int   `path:"page"`
+		Both  string `path:"both" query:"both"`
+	}
+	n := 2
+	u, err = encoder.URL("/", "/indexes/{index}/pages/{page}/{$}", page{Index: "books", Page: &n, Both: "x"})
+	test.NoError(err)
+	test.Equal("/indexes/books/pages/2/?both=x", u.String())
+
+	_, err = encoder.URL("/", "/indexes/{index}/pages/{page}", page{Page: &n})
+	test.EqualError(err, `qs: the wildcard {index} of the route "/indexes/{index}/pages/{page}" is empty`)
+
+	_, err = encoder.URL("/", "/indexes/{index}/pages/{page}/{total}", page{Index: "books", Page: &n})
+	test.True(errors.Is(err, ErrMissingPathValue))
+
+	_, err = encoder.URL("/", "/indexes/{index}", page{Index: "books", Page: &n})
+	test.True(errors.Is(err, ErrMissingPathValue))
+	test.EqualError(err, `qs: the route "/indexes/{index}" has no {page} wildcard: missing path value`)
+
+	_, err = encoder.URL("/", "indexes/{index}", page{})
+	test.Error(err)
+
+	_, err = encoder.URL("/", "/indexes", 1)
+	test.Error(err)
+
+	// the path tag alias follows the Decoder's
+	type aliased struct {
+		Index string `uri:"index"`
+	}
+	u, err = NewEncoder(WithEncoderPathTagAlias("uri")).URL("", "/indexes/{index}", aliased{Index: "books"})
+	test.NoError(err)
+	test.Equal("/indexes/books", u.String())
+}
@@ -0,0 +1,465 @@
+package qs
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// validateTag is the struct tag holding the validation rules of a field
+const validateTag = "validate"
+
+// ErrValidation matches every ValidationError with errors.Is.
+var ErrValidation = errors.New("validation failed")
+
+// ValidationError is the cause of the FieldError reported for a field
+// breaking one of the rules of its `validate` tag.
+type ValidationError struct {
+	// Rule is the rule as written in the tag, e.g. `min=1`
+	Rule    string
+	message string
+}
+
+func (e *ValidationError) Error() string {
+	return e.message
+}
+
+// Is reports whether target is ErrValidation.
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrValidation
+}
+
+// validationRule is a compiled rule of a `validate` tag.
+type validationRule struct {
+	rule    string
+	message string
+	// rules other than required and required_with skip absent parameters
+	// without a default, an optional parameter is only checked when it is
+	// given
+	checkZero bool
+	// required is met by a parameter given with a value, even one binding
+	// the zero value like `limit=0`
+	acceptSent bool
+	valid      func(field reflect.Value, parent reflect.Value) bool
+}
+
+// compileRules compiles the `validate` tag of the field of the struct type
+// parent. Cross-field rules name other fields of parent by their Go name, the
+// messages use their parameter names for tag.
+func compileRules(field reflect.StructField, parent reflect.Type, tag string) ([]*validationRule, error) {
+	text := field.Tag.Get(validateTag)
+	if text == "" {
+		return nil, nil
+	}
+	typ := derefType(field.Type)
+	if isPresenceType(typ) {
+		typ = derefType(presenceElemType(typ))
+	}
+
+	var rules []*validationRule
+	for _, rule := range strings.Split(text, ",") {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+		name, op, param := splitRule(rule)
+		r := &validationRule{rule: rule}
+
+		switch {
+		case name == "required" && op == "":
+			r.message = "is required"
+			r.checkZero = true
+			r.acceptSent = true
+			r.valid = func(field reflect.Value, _ reflect.Value) bool {
+				return !isZeroValue(field)
+			}
+		case (name == "min" || name == "max") && op == "=":
+			limit, err := strconv.ParseFloat(param, 64)
+			if err != nil || !hasSize(typ) {
+				return nil, invalidRule(rule, field)
+			}
+			if name == "min" {
+				r.message = "must be at least " + param
+				r.valid = func(field reflect.Value, _ reflect.Value) bool {
+					return sizeOf(field) >= limit
+				}
+			} else {
+				r.message = "must be at most " + param
+				r.valid = func(field reflect.Value, _ reflect.Value) bool {
+					return sizeOf(field) <= limit
+				}
+			}
+			if isLengthType(typ) {
+				r.message = "length " + r.message
+			}
+		case name == "len" && op != "":
+			limit, err := strconv.Atoi(param)
+			if err != nil || !isLengthType(typ) {
+				return nil, invalidRule(rule, field)
+			}
+			switch op {
+			case "<=":
+				r.message = "length must be at most " + param
+				r.valid = func(field reflect.Value, _ reflect.Value) bool {
+					return lengthOf(field) <= limit
+				}
+			case ">=":
+				r.message = "length must be at least " + param
+				r.valid = func(field reflect.Value, _ reflect.Value) bool {
+					return lengthOf(field) >= limit
+				}
+			default:
+				r.message = "length must be " + param
+				r.valid = func(field reflect.Value, _ reflect.Value) bool {
+					return lengthOf(field) == limit
+				}
+			}
+		case name == "oneof" && op == "=":
+			allowed := make(map[string]struct{})
+			for _, v := range strings.Fields(param) {
+				allowed[v] = struct{}{}
+			}
+			if len(allowed) == 0 || !isScalarKind(typ.Kind()) &&
+				!(isListType(typ) && isScalarKind(derefType(listElemType(typ)).Kind())) {
+				return nil, invalidRule(rule, field)
+			}
+			r.message = "must be one of " + strings.Join(strings.Fields(param), " ")
+			r.valid = func(field reflect.Value, _ reflect.Value) bool {
+				return isOneOf(field, allowed)
+			}
+		case (name == "required_with" || name == "excluded_with") && op == "=":
+			other, ok := parent.FieldByName(param)
+			if !ok || len(other.Index) != 1 {
+				return nil, invalidRule(rule, field)
+			}
+			otherName, _ := parseTag(other.Tag.Get(tag))
+			if otherName == "" {
+				otherName = other.Name
+			}
+			index := other.Index[0]
+			if name == "required_with" {
+				r.message = "is required with " + otherName
+				r.checkZero = true
+				r.valid = func(field reflect.Value, parent reflect.Value) bool {
+					return isZeroValue(parent.Field(index)) || !isZeroValue(field)
+				}
+			} else {
+				r.message = "is not allowed with " + otherName
+				r.valid = func(_ reflect.Value, parent reflect.Value) bool {
+					return isZeroValue(parent.Field(index))
+				}
+			}
+		default:
+			return nil, invalidRule(rule, field)
+		}
+		rules = append(rules, r)
+	}
+	return rules, nil
+}
+
+func invalidRule(rule string, field reflect.StructField) error {
+	return fmt.Errorf("invalid validate rule %q for field %s of type %v", rule, field.Name, field.Type)
+}
+
+// splitRule splits a rule like `len<=64` into its name, operator and
+// parameter.
+func splitRule(rule string) (string, string, string) {
+	for _, op := range []string{"<=", ">=", "="} {
+		if i := strings.Index(rule, op); i >= 0 {
+			return rule[:i], op, rule[i+len(op):]
+		}
+	}
+	return rule, "", ""
+}
+
+// validate evaluates the rules of the fields bound with tag from data to dest,
+// stopping at the first failure unless the binder collects errors. Keys
+// reported by the binding errors in failed are skipped.
+func (b *DefaultBinder) validate(dest interface{}, tag string, data map[string][]string, failed map[string]bool) (FieldErrors, error) {
+	val := reflect.ValueOf(dest)
+	for val.Kind() == reflect.Ptr {
+		if val.IsNil() {
+			return nil, nil
+		}
+		val = val.Elem()
+	}
+	if val.Kind() != reflect.Struct {
+		return nil, nil
+	}
+	plan, err := b.plan(val.Type(), tag)
+	if err != nil {
+		return nil, err
+	}
+	v := &validation{collect: b.collectErrors, failed: failed, params: newParamSet(data, b.caseSensitive)}
+	v.validateStruct(val, plan, "", "")
+	return v.errs, nil
+}
+
+// validation collects the failures of a validate call.
+type validation struct {
+	collect bool
+	failed  map[string]bool
+	// params the fields were bound from
+	params *paramSet
+	errs   FieldErrors
+}
+
+// done reports whether validation must stop at the first failure.
+func (v *validation) done() bool {
+	return !v.collect && len(v.errs) > 0
+}
+
+func (v *validation) validateStruct(val reflect.Value, plan *structPlan, scope string, path string) {
+	if !plan.validates {
+		return
+	}
+	for _, fieldPlan := range plan.fields {
+		if v.done() {
+			return
+		}
+		field := val.Field(fieldPlan.index)
+		if fieldPlan.anonymousPtr {
+			if field.IsNil() {
+				continue
+			}
+			field = field.Elem()
+		}
+		if fieldPlan.kind == planInline {
+			v.validateStruct(field, fieldPlan.plan, scope, path)
+			continue
+		}
+
+		key := fieldPlan.key(scope)
+		if v.failed[key] {
+			continue
+		}
+		v.validateField(field, val, fieldPlan, key, fieldPlan.path(path))
+		if fieldPlan.plan == nil || v.done() {
+			continue
+		}
+
+		field = derefValue(field)
+		if !field.IsValid() {
+			continue
+		}
+		switch fieldPlan.kind {
+		case planNested:
+			v.validateStruct(field, fieldPlan.plan, key, fieldPlan.path(path))
+		case planNestedList:
+			for i := 0; i < field.Len() && !v.done(); i++ {
+				if elem := derefValue(field.Index(i)); elem.IsValid() {
+					index := "[" + strconv.Itoa(i) + "]"
+					v.validateStruct(elem, fieldPlan.plan, key+index, fieldPlan.path(path)+index)
+				}
+			}
+		case planMap:
+			keys := field.MapKeys()
+			sortedKeys := make([]string, len(keys))
+			byName := make(map[string]reflect.Value, len(keys))
+			for i, k := range keys {
+				sortedKeys[i] = fmt.Sprint(k.Interface())
+				byName[sortedKeys[i]] = k
+			}
+			sort.Strings(sortedKeys)
+			for _, name := range sortedKeys {
+				if v.done() {
+					break
+				}
+				if elem := derefValue(field.MapIndex(byName[name])); elem.IsValid() {
+					v.validateStruct(elem, fieldPlan.plan, key+"["+name+"]", fieldPlan.path(path)+"["+name+"]")
+				}
+			}
+		}
+	}
+}
+
+// validateField checks the rules of a field of the struct parent, reporting
+// the first one it breaks.
+func (v *validation) validateField(field reflect.Value, parent reflect.Value, fieldPlan *fieldPlan, key string, path string) {
+	given := v.given(fieldPlan, key)
+	for _, rule := range fieldPlan.rules {
+		if !given && !rule.checkZero {
+			continue
+		}
+		if rule.acceptSent && given && derefValue(field).IsValid() && v.sent(fieldPlan, key) {
+			continue
+		}
+		if rule.valid(field, parent) {
+			continue
+		}
+		fieldErr := &FieldError{
+			Key:   key,
+			Field: path,
+			Err:   &ValidationError{Rule: rule.rule, message: rule.message},
+		}
+		if value := derefValue(field); value.IsValid() && isScalarKind(value.Kind()) && given {
+			fieldErr.Value = fmt.Sprint(value.Interface())
+		}
+		v.errs = append(v.errs, fieldErr)
+		return
+	}
+}
+
+// given reports whether the field was bound from a parameter named key, or
+// from its default.
+func (v *validation) given(fieldPlan *fieldPlan, key string) bool {
+	if fieldPlan.defaults != nil {
+		return true
+	}
+	switch fieldPlan.kind {
+	case planValue:
+		if fieldPlan.isList {
+			_, exists := v.params.lookupList(key, "", fieldPlan.listFormat)
+			return exists
+		}
+		_, exists := v.params.lookup(key, "")
+		return exists
+	default:
+		_, exists := v.params.lookup(key, "")
+		return exists || v.params.hasScope(key)
+	}
+}
+
+// sent reports whether the value field was bound from a non-empty parameter
+// named key, or from a non-empty default.
+func (v *validation) sent(fieldPlan *fieldPlan, key string) bool {
+	if fieldPlan.kind != planValue {
+		return false
+	}
+	values, exists := v.params.lookup(key, "")
+	if fieldPlan.isList {
+		values, exists = v.params.lookupList(key, "", fieldPlan.listFormat)
+	}
+	if !exists {
+		values = fieldPlan.defaults
+	}
+	for _, value := range values {
+		if value != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// derefValue follows pointers and the value of Fields, it returns the zero
+// Value for a nil pointer.
+func derefValue(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	if v.IsValid() && isPresenceType(v.Type()) {
+		return derefValue(presenceOf(v).elem())
+	}
+	return v
+}
+
+// isZeroValue reports whether v is a nil pointer, an unset Field, an empty
+// slice or map, or the zero value of its type. A set Field is not zero, so
+// `required` accepts an explicitly empty parameter bound to a Field.
+func isZeroValue(v reflect.Value) bool {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return true
+		}
+		v = v.Elem()
+	}
+	if isPresenceType(v.Type()) {
+		return !presenceOf(v).IsSet()
+	}
+	v = derefValue(v)
+	if !v.IsValid() {
+		return true
+	}
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	default:
+		return v.IsZero()
+	}
+}
+
+func isScalarKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// isLengthType reports whether the size of typ is its length.
+func isLengthType(typ reflect.Type) bool {
+	switch typ.Kind() {
+	case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+		return true
+	}
+	return false
+}
+
+// hasSize reports whether min and max apply to typ, comparing numbers by
+// value and strings, lists and maps by length.
+func hasSize(typ reflect.Type) bool {
+	switch typ.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return isLengthType(typ)
+}
+
+func sizeOf(v reflect.Value) float64 {
+	v = derefValue(v)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		return v.Float()
+	default:
+		return float64(lengthOf(v))
+	}
+}
+
+// lengthOf returns the number of characters of a string, or the length of a
+// list or map.
+func lengthOf(v reflect.Value) int {
+	v = derefValue(v)
+	switch v.Kind() {
+	case reflect.String:
+		return utf8.RuneCountInString(v.String())
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return v.Len()
+	default:
+		return 0
+	}
+}
+
+// isOneOf reports whether the scalar v, or every element of the list v, is
+// one of the allowed values.
+func isOneOf(v reflect.Value, allowed map[string]struct{}) bool {
+	v = derefValue(v)
+	if v.Kind() == reflect.Slice || v.Kind() == reflect.Array {
+		for i := 0; i < v.Len(); i++ {
+			if !isOneOf(v.Index(i), allowed) {
+				return false
+			}
+		}
+		return true
+	}
+	if !v.IsValid() {
+		return true
+	}
+	_, ok := allowed[fmt.Sprint(v.Interface())]
+	return ok
+}
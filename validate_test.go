@@ -0,0 +1,153 @@
+package qs
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeValidate(t *testing.T) {
+	test := assert.New(t)
+
+	type filter struct {
+		Field string `query:"field" validate:"required"`
+	}
+	type query struct {
+		Index   string            `path:"index" validate:"required,len<=8"`
+		Limit   int               `query:"limit" validate:"min=1,max=100"`
+		Sort    string            `query:"sort" validate:"oneof=asc desc"`
+		Q       string            `query:"q" validate:"len<=5"`
+		Tags    []string          `query:"tags" validate:"max=2,oneof=a b c"`
+		Cursor  string            `query:"cursor" validate:"excluded_with=Page"`
+		Page    *int              `query:"page" validate:"min=1"`
+		Order   string            `query:"order" validate:"required_with=Sort"`
+		Filters []filter          `query:"filters"`
+		Ranges  map[string]filter `query:"ranges"`
+		Name    string            `query:"name" validate:"required"`
+	}
+
+	decoder := NewDecoder(WithPathValues(map[string]string{"index": "default"}))
+	var dest query
+	err := decoder.Decode("/?limit=10&sort=asc&order=name&tags=a&tags=b&filters[0][field]=x&name=n", &dest)
+	test.NoError(err)
+	test.Equal(10, dest.Limit)
+
+	tests := []struct {
+		uri   string
+		key   string
+		field string
+		value string
+		rule  string
+		msg   string
+	}{
+		{uri: "/?name=n&sort=x", key: "sort", field: "Sort", value: "x", rule: "oneof=asc desc",
+			msg: `qs: parameter "sort" (field Sort): invalid value "x": must be one of asc desc`},
+		{uri: "/?name=n&limit=101", key: "limit", field: "Limit", value: "101", rule: "max=100"},
+		{uri: "/?name=n&limit=-1", key: "limit", field: "Limit", value: "-1", rule: "min=1"},
+		// zero values are checked when they are given
+		{uri: "/?name=n&limit=0", key: "limit", field: "Limit", value: "0", rule: "min=1",
+			msg: `qs: parameter "limit" (field Limit): invalid value "0": must be at least 1`},
+		{uri: "/?name=n&page=0", key: "page", field: "Page", value: "0", rule: "min=1"},
+		{uri: "/?name=n&Limit=0", key: "limit", field: "Limit", value: "0", rule: "min=1"},
+		{uri: "/?name=n&q=abcdef", key: "q", field: "Q", value: "abcdef", rule: "len<=5",
+			msg: `qs: parameter "q" (field Q): invalid value "abcdef": length must be at most 5`},
+		{uri: "/?name=n&tags=a&tags=b&tags=c", key: "tags", field: "Tags", rule: "max=2"},
+		{uri: "/?name=n&tags=d", key: "tags", field: "Tags", rule: "oneof=a b c"},
+		{uri: "/?name=n&cursor=x&page=2", key: "cursor", field: "Cursor", value: "x", rule: "excluded_with=Page",
+			msg: `qs: parameter "cursor" (field Cursor): invalid value "x": is not allowed with page`},
+		{uri: "/?name=n&sort=asc", key: "order", field: "Order", rule: "required_with=Sort",
+			msg: `qs: parameter "order" (field Order): is required with sort`},
+		{uri: "/?name=n&filters[0][field]=x&filters[1][field]=", key: "filters[1][field]", field: "Filters[1].Field", rule: "required"},
+		{uri: "/?name=n&ranges[a][field]=", key: "ranges[a][field]", field: "Ranges[a].Field", rule: "required"},
+		{uri: "/", key: "name", field: "Name", rule: "required",
+			msg: `qs: parameter "name" (field Name): is required`},
+	}
+	for _, tt := range tests {
+		err := decoder.Decode(tt.uri, &query{})
+		test.True(errors.Is(err, ErrValidation), tt.uri)
+		var fieldErr *FieldError
+		var validationErr *ValidationError
+		if test.True(errors.As(err, &fieldErr), tt.uri) && test.True(errors.As(err, &validationErr), tt.uri) {
+			test.Equal(tt.key, fieldErr.Key, tt.uri)
+			test.Equal(tt.field, fieldErr.Field, tt.uri)
+			test.Equal(tt.value, fieldErr.Value, tt.uri)
+			test.Equal(tt.rule, validationErr.Rule, tt.uri)
+		}
+		if tt.msg != "" {
+			test.EqualError(err, tt.msg, tt.uri)
+		}
+	}
+
+	// path fields are validated too
+	err = NewDecoder(WithPathValues(map[string]string{"index": "too-long-index"})).Decode("/?name=n", &query{})
+	var fieldErr *FieldError
+	if test.True(errors.As(err, &fieldErr)) {
+		test.Equal("index", fieldErr.Key)
+	}
+
+	// conversion and validation failures are collected together, the
+	// parameters that failed to bind aren't validated
+	collect := NewDecoder(WithPathValues(map[string]string{"index": "default"}), WithCollectErrors())
+	err = collect.Decode("/?limit=x&sort=x&cursor=c&page=1", &query{})
+	var errs FieldErrors
+	if test.True(errors.As(err, &errs)) {
+		keys := make([]string, 0, len(errs))
+		for _, err := range errs {
+			keys = append(keys, err.Key)
+		}
+		test.Equal([]string{"limit", "sort", "cursor", "order", "name"}, keys)
+	}
+
+	// validation failures are merged with conversion failures in field order
+	type item struct {
+		Count int `query:"count" validate:"max=5"`
+		Name  int `query:"name"`
+	}
+	type ordered struct {
+		A     int    `query:"a"`
+		B     int    `query:"b" validate:"max=5"`
+		C     int    `query:"c"`
+		Items []item `query:"items"`
+	}
+	err = NewDecoder(WithCollectErrors()).Decode("/?a=x&b=9&c=y&items[0][name]=x&items[0][count]=9&items[1][count]=9", &ordered{})
+	if test.True(errors.As(err, &errs)) {
+		keys := make([]string, 0, len(errs))
+		for _, err := range errs {
+			keys = append(keys, err.Key)
+		}
+		test.Equal([]string{"a", "b", "c", "items[0][count]", "items[0][name]", "items[1][count]"}, keys)
+	}
+	// a required parameter given with a value is met by its zero value
+	type paging struct {
+		Limit int `query:"limit" validate:"required"`
+	}
+	test.NoError(NewDecoder().Decode("/?limit=0", &paging{}))
+	test.Error(NewDecoder().Decode("/?limit=", &paging{}))
+	test.Error(NewDecoder().Decode("/", &paging{}))
+}
+
+func TestInvalidValidateRule(t *testing.T) {
+	test := assert.New(t)
+
+	tests := []any{
+		&struct {
+			Limit int `query:"limit" validate:"len<=5"`
+		}{},
+		&struct {
+			Limit int `query:"limit" validate:"min=one"`
+		}{},
+		&struct {
+			Limit int `query:"limit" validate:"between=1 5"`
+		}{},
+		&struct {
+			Limit int `query:"limit" validate:"required_with=Missing"`
+		}{},
+		&struct {
+			Filter map[string]string `query:"filter" validate:"oneof=a b"`
+		}{},
+	}
+	for _, dest := range tests {
+		test.Error(NewDecoder().Decode("/?limit=1", dest))
+	}
+}